@@ -0,0 +1,113 @@
+package errs
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 番号のついたエラー。番号は要素の位置や試行の回数などを表す。
+type Error struct {
+	Index int
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return "[" + strconv.Itoa(e.Index) + "] " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// 複数のエラーをまとめたエラー。
+// errors.Is と errors.As は、まとめたエラーのいずれかに一致すれば true を返す。
+// 複数のゴルーチンから Add してもよい。
+type Errors struct {
+	mu   sync.Mutex
+	errs []*Error
+}
+
+// 空のエラーの集まりをつくる。
+func New() *Errors {
+	return &Errors{}
+}
+
+// エラーを番号とともに追加する。err が nil の場合は何もしない。
+func (e *Errors) Add(index int, err error) {
+	if err == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.errs = append(e.errs, &Error{Index: index, Err: err})
+}
+
+// エラーの数を返す。
+func (e *Errors) Len() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.errs)
+}
+
+// 追加した順にエラーを返す。
+func (e *Errors) Errs() []*Error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]*Error{}, e.errs...)
+}
+
+// 最後に追加したエラーを返す。エラーが無い場合は nil を返す。
+func (e *Errors) Last() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.errs) == 0 {
+		return nil
+	}
+	return e.errs[len(e.errs)-1].Err
+}
+
+// エラーがある場合は e を、無い場合は nil を返す。
+func (e *Errors) ErrOrNil() error {
+	if e.Len() == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *Errors) Error() string {
+	errs := e.Errs()
+	s := make([]string, len(errs))
+	for i, err := range errs {
+		s[i] = err.Error()
+	}
+	return strings.Join(s, "; ")
+}
+
+func (e *Errors) Is(target error) bool {
+	for _, err := range e.Errs() {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Errors) As(target any) bool {
+	for _, err := range e.Errs() {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Errors) Unwrap() []error {
+	errs := e.Errs()
+	dst := make([]error, len(errs))
+	for i, err := range errs {
+		dst[i] = err
+	}
+	return dst
+}
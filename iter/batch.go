@@ -0,0 +1,124 @@
+package iter
+
+import "time"
+
+// Batch の設定。0 の項目は使われない。
+type BatchOptions[V any] struct {
+	// まとめる値の最大数。
+	MaxCount int
+	// まとめる値の合計サイズの最大値。サイズは SizeOf で計算する。
+	MaxBytes int
+	// 値のサイズを返す関数。nil の場合 MaxBytes は使われない。
+	SizeOf func(V) (int, error)
+	// 最初の値を受け取ってから待つ最大時間。
+	MaxWait time.Duration
+}
+
+func NewBatchOptions[V any]() *BatchOptions[V] {
+	return &BatchOptions[V]{
+		MaxCount: 100,
+		MaxBytes: 0,
+		SizeOf:   nil,
+		MaxWait:  0,
+	}
+}
+
+func (opts *BatchOptions[V]) WithMaxCount(maxCount int) *BatchOptions[V] {
+	opts.MaxCount = maxCount
+	return opts
+}
+
+func (opts *BatchOptions[V]) WithMaxBytes(maxBytes int, sizeOf func(V) (int, error)) *BatchOptions[V] {
+	opts.MaxBytes = maxBytes
+	opts.SizeOf = sizeOf
+	return opts
+}
+
+func (opts *BatchOptions[V]) WithMaxWait(maxWait time.Duration) *BatchOptions[V] {
+	opts.MaxWait = maxWait
+	return opts
+}
+
+// 値をまとめたスライスのイテレータを返す。
+// 値の数が MaxCount に達するか、合計サイズが MaxBytes に達するか、最初の値から MaxWait が経過したときに区切る。
+// ひとつの値のサイズが MaxBytes を超える場合は、その値だけのスライスになる。
+// MaxWait を使う場合、FromChan や Prefetch でつくったイテレータ以外はゴルーチンの中で１個先読みされる。
+// opts が nil の場合は NewBatchOptions() を使う。
+func Batch[V any](iter Iter[V], opts *BatchOptions[V]) Iter[[]V] {
+	if opts == nil {
+		opts = NewBatchOptions[V]()
+	}
+
+	var ch *chanIter[V]
+	if opts.MaxWait > 0 {
+		if c, ok := iter.(*chanIter[V]); ok {
+			ch = c
+		} else {
+			ch = Prefetch(iter, 1).(*chanIter[V])
+		}
+		iter = ch
+	}
+
+	var pending V
+	hasPending := false
+	return FromFuncWithClose(func(ctx Context) ([]V, bool) {
+		batch := []V{}
+		size := 0
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		defer func() {
+			if timer != nil {
+				timer.Stop()
+			}
+		}()
+
+		for {
+			var v V
+			var ok bool
+			if hasPending {
+				v, ok, hasPending = pending, true, false
+				pending = *new(V)
+			} else if ch != nil {
+				var timedOut bool
+				v, ok, timedOut = ch.nextWithin(timeout)
+				if timedOut {
+					return batch, true
+				}
+			} else {
+				v, ok = iter.Next()
+			}
+			if !ok {
+				if err := iter.Err(); err != nil {
+					ctx.SetErr(err)
+					return nil, false
+				}
+				return batch, len(batch) > 0
+			}
+
+			if opts.MaxBytes > 0 && opts.SizeOf != nil {
+				n, err := opts.SizeOf(v)
+				if err != nil {
+					ctx.SetErr(err)
+					return nil, false
+				}
+				if len(batch) > 0 && size+n > opts.MaxBytes {
+					pending, hasPending = v, true
+					return batch, true
+				}
+				size += n
+			}
+
+			batch = append(batch, v)
+			if len(batch) == 1 && opts.MaxWait > 0 {
+				timer = time.NewTimer(opts.MaxWait)
+				timeout = timer.C
+			}
+			if opts.MaxCount > 0 && len(batch) >= opts.MaxCount {
+				return batch, true
+			}
+			if opts.MaxBytes > 0 && opts.SizeOf != nil && size >= opts.MaxBytes {
+				return batch, true
+			}
+		}
+	}, closer(iter))
+}
@@ -0,0 +1,27 @@
+package iter
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBatchMaxWaitKeepsValuesBeforeError(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		iter := Batch(failAfter(5), NewBatchOptions[int]().WithMaxCount(2).WithMaxWait(time.Second))
+		got := [][]int{}
+		for {
+			batch, ok := iter.Next()
+			if !ok {
+				break
+			}
+			got = append(got, batch)
+		}
+		if len(got) != 2 || got[0][0] != 0 || got[1][1] != 3 {
+			t.Fatalf("run %d: got %v, want [[0 1] [2 3]]", i, got)
+		}
+		if !errors.Is(iter.Err(), errTest) {
+			t.Fatalf("run %d: err = %v, want %v", i, iter.Err(), errTest)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package iter
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sync"
+)
+
+// Cache の設定。
+type CacheOptions struct {
+	// メモリ上に保持する値の最大数。超えた分は一時ファイルに書き出す。0 の場合はすべてメモリ上に保持する。
+	MaxInMemory int
+	// 一時ファイルを作成するディレクトリ。空の場合は os.TempDir() を使う。
+	TempDir string
+	// 一時ファイルの符号化方式。
+	Codec Codec
+}
+
+func NewCacheOptions() *CacheOptions {
+	return &CacheOptions{
+		MaxInMemory: 0,
+		TempDir:     "",
+		Codec:       GobCodec,
+	}
+}
+
+func (opts *CacheOptions) WithMaxInMemory(maxInMemory int) *CacheOptions {
+	opts.MaxInMemory = maxInMemory
+	return opts
+}
+
+func (opts *CacheOptions) WithTempDir(tempDir string) *CacheOptions {
+	opts.TempDir = tempDir
+	return opts
+}
+
+func (opts *CacheOptions) WithCodec(codec Codec) *CacheOptions {
+	opts.Codec = codec
+	return opts
+}
+
+// 読んだ値を記憶して、何度でも読めるようにしたもの。
+type Cached[V any] struct {
+	mu      sync.Mutex
+	iter    Iter[V]
+	opts    *CacheOptions
+	values  []V
+	spilled int // 一時ファイルに書き出した値の数
+	file    *os.File
+	writer  *bufio.Writer
+	encoder Encoder
+	done    bool
+	err     error
+	closed  bool
+}
+
+// 値を記憶して何度でも読めるようにする。
+// 値は Iter() で作ったイテレータが最初に読んだときに元のイテレータから読まれ、以降は記憶した値が返される。
+// 一時ファイルを使う場合、使い終わったら Close を呼ぶこと。
+// opts が nil の場合は NewCacheOptions() を使う。
+func Cache[V any](iter Iter[V], opts *CacheOptions) *Cached[V] {
+	if opts == nil {
+		opts = NewCacheOptions()
+	}
+	return &Cached[V]{iter: iter, opts: opts}
+}
+
+// 先頭から読むイテレータを返す。複数のゴルーチンから同時に読んでもよい。
+func (c *Cached[V]) Iter() Iter[V] {
+	i := 0
+	var decoder Decoder
+	return FromFunc(func(ctx Context) (V, bool) {
+		v, ok, err := c.get(i, &decoder)
+		if err != nil {
+			ctx.SetErr(err)
+			return *new(V), false
+		}
+		if ok {
+			i++
+		}
+		return v, ok
+	})
+}
+
+// 元のイテレータを閉じ、一時ファイルを削除する。
+func (c *Cached[V]) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	c.values = nil
+	err := Close(c.iter)
+	if c.file != nil {
+		if err1 := c.file.Close(); err == nil {
+			err = err1
+		}
+		if err1 := os.Remove(c.file.Name()); err == nil {
+			err = err1
+		}
+		c.file = nil
+	}
+	return err
+}
+
+// i 番目の値を返す。一時ファイルに書き出した値は decoder で順に読む。
+func (c *Cached[V]) get(i int, decoder *Decoder) (V, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return *new(V), false, nil
+	}
+	for i >= len(c.values)+c.spilled {
+		if c.done {
+			return *new(V), false, c.err
+		}
+		if err := c.pull(); err != nil {
+			return *new(V), false, err
+		}
+	}
+	if i < len(c.values) {
+		return c.values[i], true, nil
+	}
+
+	if err := c.writer.Flush(); err != nil {
+		return *new(V), false, err
+	}
+	if *decoder == nil {
+		*decoder = c.codec().NewDecoder(bufio.NewReader(&cacheReader{file: c.file}))
+	}
+	v := *new(V)
+	if err := (*decoder).Decode(&v); err != nil {
+		return *new(V), false, err
+	}
+	return v, true, nil
+}
+
+// 元のイテレータから値をひとつ読んで記憶する。
+func (c *Cached[V]) pull() error {
+	v, ok := c.iter.Next()
+	if !ok {
+		c.done = true
+		c.err = c.iter.Err()
+		if err := Close(c.iter); err != nil && c.err == nil {
+			c.err = err
+		}
+		return nil
+	}
+	if c.opts.MaxInMemory <= 0 || len(c.values) < c.opts.MaxInMemory {
+		c.values = append(c.values, v)
+		return nil
+	}
+	if c.file == nil {
+		f, err := os.CreateTemp(c.opts.TempDir, "gu-cache-*")
+		if err != nil {
+			return err
+		}
+		c.file = f
+		c.writer = bufio.NewWriter(f)
+		c.encoder = c.codec().NewEncoder(c.writer)
+	}
+	if err := c.encoder.Encode(v); err != nil {
+		return err
+	}
+	c.spilled++
+	return nil
+}
+
+func (c *Cached[V]) codec() Codec {
+	if c.opts.Codec == nil {
+		return GobCodec
+	}
+	return c.opts.Codec
+}
+
+// 書き込み中のファイルを先頭から読む。
+// 読める分があるときは io.EOF を返さないので、Decoder が途中で終端と判断することはない。
+type cacheReader struct {
+	file   *os.File
+	offset int64
+}
+
+func (r *cacheReader) Read(p []byte) (int, error) {
+	n, err := r.file.ReadAt(p, r.offset)
+	r.offset += int64(n)
+	if n > 0 {
+		return n, nil
+	}
+	if err == nil {
+		err = io.EOF
+	}
+	return 0, err
+}
@@ -0,0 +1,118 @@
+package iter
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"testing"
+)
+
+func checkRange(t *testing.T, got []int, n int) {
+	t.Helper()
+	if len(got) != n {
+		t.Fatalf("got %d values, want %d", len(got), n)
+	}
+	for i, v := range got {
+		if v != i {
+			t.Fatalf("got[%d] = %d, want %d", i, v, i)
+		}
+	}
+}
+
+func tempFiles(t *testing.T, dir string) int {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(entries)
+}
+
+func TestCacheSpill(t *testing.T) {
+	for _, codec := range []Codec{GobCodec, JSONCodec} {
+		dir := t.TempDir()
+		c := Cache(Range(0, 100, 1), NewCacheOptions().WithMaxInMemory(10).WithTempDir(dir).WithCodec(codec))
+		for i := 0; i < 3; i++ {
+			got, err := ToSlice(c.Iter())
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRange(t, got, 100)
+		}
+		if n := tempFiles(t, dir); n != 1 {
+			t.Fatalf("%d temp files, want 1", n)
+		}
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if n := tempFiles(t, dir); n != 0 {
+			t.Fatalf("%d temp files after Close, want 0", n)
+		}
+	}
+}
+
+func TestCacheSpillInterleaved(t *testing.T) {
+	c := Cache(Range(0, 100, 1), NewCacheOptions().WithMaxInMemory(10).WithTempDir(t.TempDir()))
+	defer c.Close()
+
+	// a が元のイテレータを読み進め、b は書き込み中の一時ファイルを追いかける。
+	a, b := c.Iter(), c.Iter()
+	gotA, gotB := []int{}, []int{}
+	for i := 0; i < 100; i++ {
+		v, ok := a.Next()
+		if !ok {
+			t.Fatal(a.Err())
+		}
+		gotA = append(gotA, v)
+		v, ok = b.Next()
+		if !ok {
+			t.Fatal(b.Err())
+		}
+		gotB = append(gotB, v)
+	}
+	checkRange(t, gotA, 100)
+	checkRange(t, gotB, 100)
+}
+
+func TestCacheSpillConcurrent(t *testing.T) {
+	c := Cache(Range(0, 1000, 1), NewCacheOptions().WithMaxInMemory(10).WithTempDir(t.TempDir()))
+	defer c.Close()
+
+	var wg sync.WaitGroup
+	results := make([][]int, 8)
+	errs := make([]error, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = ToSlice(c.Iter())
+		}(i)
+	}
+	wg.Wait()
+	for i := range results {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+		checkRange(t, results[i], 1000)
+	}
+}
+
+func TestCacheError(t *testing.T) {
+	c := Cache(failAfter(20), NewCacheOptions().WithMaxInMemory(5).WithTempDir(t.TempDir()))
+	defer c.Close()
+	for i := 0; i < 2; i++ {
+		iter := c.Iter()
+		got := []int{}
+		for {
+			v, ok := iter.Next()
+			if !ok {
+				break
+			}
+			got = append(got, v)
+		}
+		checkRange(t, got, 20)
+		if !errors.Is(iter.Err(), errTest) {
+			t.Fatalf("err = %v, want %v", iter.Err(), errTest)
+		}
+	}
+}
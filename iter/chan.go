@@ -0,0 +1,156 @@
+package iter
+
+import (
+	"context"
+	"time"
+)
+
+// チャネルからイテレータをつくる。
+// チャネルが閉じられたら終了する。
+func FromChan[V any](ch <-chan V) Iter[V] {
+	return &chanIter[V]{ch: ch}
+}
+
+// チャネルとエラーのチャネルからイテレータをつくる。
+// errc からエラーを受け取ったら、ch にすでに届いている値を返してから停止し、Err はそのエラーを返す。
+// ch を閉じたあとは、errc にエラーを送るか errc を閉じること。
+func FromChanWithErr[V any](ch <-chan V, errc <-chan error) Iter[V] {
+	return &chanIter[V]{ch: ch, errc: errc}
+}
+
+// イテレータの値をゴルーチンの中で読み、チャネルに送る。
+// 値をすべて送るとチャネルは閉じられ、エラーのチャネルには最後に Err が送られる。
+// ctx が終了した場合は停止し、エラーのチャネルには ctx.Err() が送られる。
+func ToChan[V any](ctx context.Context, iter Iter[V]) (<-chan V, <-chan error) {
+	return toChan(ctx, iter, 0)
+}
+
+// イテレータの値を最大n個までゴルーチンの中で先読みするイテレータを返す。
+// ゴルーチンは読んだ値をひとつ持って待つので、n が 1 未満の場合は 1 とみなす。
+// 途中で読むのをやめる場合は Close を呼ぶこと。
+func Prefetch[V any](iter Iter[V], n int) Iter[V] {
+	if n < 1 {
+		n = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	// チャネルに入る n-1 個と、送るのを待っている１個で n 個になる。
+	ch, errc := toChan(ctx, iter, n-1)
+	return &chanIter[V]{
+		ch:   ch,
+		errc: errc,
+		close: func() error {
+			cancel()
+			for range ch {
+			}
+			return nil
+		},
+	}
+}
+
+func toChan[V any](ctx context.Context, iter Iter[V], size int) (<-chan V, <-chan error) {
+	ch := make(chan V, size)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		defer close(ch)
+		iter := WithContext(ctx, iter)
+		defer Close(iter)
+		for {
+			v, ok := iter.Next()
+			if !ok {
+				if err := iter.Err(); err != nil {
+					errc <- err
+				}
+				return
+			}
+			select {
+			case ch <- v:
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			}
+		}
+	}()
+	return ch, errc
+}
+
+type chanIter[V any] struct {
+	ch      <-chan V
+	errc    <-chan error
+	close   func() error
+	closed  bool
+	pending error // errc から受け取ったが、まだ ch に値が残っているかもしれないエラー
+	err     error
+}
+
+func (iter *chanIter[V]) Next() (V, bool) {
+	v, ok, _ := iter.nextWithin(nil)
+	return v, ok
+}
+
+// 次の値を返す。timeout から値を受け取った場合は第３戻り値に true を返す。
+func (iter *chanIter[V]) nextWithin(timeout <-chan time.Time) (V, bool, bool) {
+	if iter.err != nil || iter.closed {
+		return *new(V), false, false
+	}
+	if iter.pending != nil {
+		// エラーより前に送られた値を先に返す。
+		select {
+		case v, ok := <-iter.ch:
+			if ok {
+				return v, true, false
+			}
+		default:
+		}
+		iter.err = iter.pending
+		iter.end()
+		return *new(V), false, false
+	}
+	for {
+		select {
+		case v, ok := <-iter.ch:
+			if ok {
+				return v, true, false
+			}
+			if iter.errc != nil {
+				iter.err = <-iter.errc
+			}
+			iter.end()
+			return *new(V), false, false
+
+		case err, ok := <-iter.errc:
+			if !ok || err == nil {
+				iter.errc = nil
+				continue
+			}
+			iter.pending = err
+			iter.errc = nil
+			return iter.nextWithin(timeout)
+
+		case <-timeout:
+			return *new(V), false, true
+		}
+	}
+}
+
+// 終端に達したら資源を解放する。
+func (iter *chanIter[V]) end() {
+	if err := iter.Close(); err != nil && iter.err == nil {
+		iter.err = err
+	}
+}
+
+func (iter *chanIter[V]) Err() error {
+	return iter.err
+}
+
+func (iter *chanIter[V]) Close() error {
+	if iter.closed {
+		return nil
+	}
+	iter.closed = true
+	if iter.close == nil {
+		return nil
+	}
+	return iter.close()
+}
@@ -0,0 +1,121 @@
+package iter
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var errTest = errors.New("test")
+
+// n個の値を返したあと errTest で停止するイテレータ。
+func failAfter(n int) Iter[int] {
+	return Concat(Range(0, n, 1), FromFunc(func(ctx Context) (int, bool) {
+		ctx.SetErr(errTest)
+		return 0, false
+	}))
+}
+
+func TestPrefetchKeepsValuesBeforeError(t *testing.T) {
+	for i := 0; i < 500; i++ {
+		iter := Prefetch(failAfter(5), 8)
+		got := []int{}
+		for {
+			v, ok := iter.Next()
+			if !ok {
+				break
+			}
+			got = append(got, v)
+		}
+		if len(got) != 5 {
+			t.Fatalf("run %d: got %v, want 5 values", i, got)
+		}
+		if !errors.Is(iter.Err(), errTest) {
+			t.Fatalf("run %d: err = %v, want %v", i, iter.Err(), errTest)
+		}
+	}
+}
+
+func TestPrefetchClose(t *testing.T) {
+	iter := Prefetch(Range(0, 1000, 1), 4)
+	if v, ok := iter.Next(); !ok || v != 0 {
+		t.Fatalf("Next() = %v, %v", v, ok)
+	}
+	if err := Close(iter); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := iter.Next(); ok {
+		t.Fatal("Next() after Close returned a value")
+	}
+}
+
+func TestToChan(t *testing.T) {
+	ch, errc := ToChan(context.Background(), failAfter(3))
+	got := []int{}
+	for v := range ch {
+		got = append(got, v)
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %v, want 3 values", got)
+	}
+	if err := <-errc; !errors.Is(err, errTest) {
+		t.Fatalf("err = %v, want %v", err, errTest)
+	}
+}
+
+func TestFromChanWithErr(t *testing.T) {
+	ch := make(chan int, 3)
+	errc := make(chan error, 1)
+	ch <- 1
+	ch <- 2
+	errc <- errTest
+	iter := FromChanWithErr(ch, errc)
+	got := []int{}
+	for {
+		v, ok := iter.Next()
+		if !ok {
+			break
+		}
+		got = append(got, v)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %v, want [1 2]", got)
+	}
+	if !errors.Is(iter.Err(), errTest) {
+		t.Fatalf("err = %v, want %v", iter.Err(), errTest)
+	}
+}
+
+func TestPrefetchReadsAheadN(t *testing.T) {
+	for _, c := range []struct{ n, want int }{{0, 1}, {1, 1}, {3, 3}} {
+		var reads int32
+		r := Range(0, 100, 1)
+		src := FromFunc(func(ctx Context) (int, bool) {
+			atomic.AddInt32(&reads, 1)
+			return r.Next()
+		})
+		iter := Prefetch(src, c.n)
+
+		waitReads := func(want int) {
+			t.Helper()
+			deadline := time.Now().Add(time.Second)
+			for int(atomic.LoadInt32(&reads)) < want && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			time.Sleep(20 * time.Millisecond)
+			if got := int(atomic.LoadInt32(&reads)); got != want {
+				t.Fatalf("Prefetch(%d): read %d values ahead, want %d", c.n, got, want)
+			}
+		}
+		waitReads(c.want)
+		if _, ok := iter.Next(); !ok {
+			t.Fatal(iter.Err())
+		}
+		waitReads(c.want + 1)
+		if err := Close(iter); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
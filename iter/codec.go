@@ -0,0 +1,61 @@
+package iter
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"io"
+)
+
+// 値を一時ファイルなどに書き出すときの符号化方式。
+type Codec interface {
+	NewEncoder(w io.Writer) Encoder
+	NewDecoder(r io.Reader) Decoder
+}
+
+type Encoder interface {
+	Encode(v any) error
+}
+
+type Decoder interface {
+	Decode(v any) error
+}
+
+// encoding/gob で符号化する。
+var GobCodec Codec = gobCodec{}
+
+// encoding/json で符号化する。
+var JSONCodec Codec = jsonCodec{}
+
+type gobCodec struct{}
+
+func (gobCodec) NewEncoder(w io.Writer) Encoder {
+	return gob.NewEncoder(w)
+}
+
+func (gobCodec) NewDecoder(r io.Reader) Decoder {
+	return gob.NewDecoder(r)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) NewEncoder(w io.Writer) Encoder {
+	return json.NewEncoder(w)
+}
+
+func (jsonCodec) NewDecoder(r io.Reader) Decoder {
+	return json.NewDecoder(r)
+}
+
+// Decoder からイテレータをつくる。io.EOF で終了する。
+func fromDecoder[V any](decoder Decoder) Iter[V] {
+	return FromFunc(func(ctx Context) (V, bool) {
+		v := *new(V)
+		if err := decoder.Decode(&v); err != nil {
+			if err != io.EOF {
+				ctx.SetErr(err)
+			}
+			return v, false
+		}
+		return v, true
+	})
+}
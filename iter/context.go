@@ -0,0 +1,335 @@
+package iter
+
+import (
+	"context"
+
+	"github.com/thamaji/gu/must"
+	"golang.org/x/exp/constraints"
+)
+
+// context.Context が終了したら停止するイテレータを返す。
+// 停止した場合 Err は ctx.Err() を返す。
+func WithContext[V any](ctx context.Context, iter Iter[V]) Iter[V] {
+	return FromFuncWithClose(func(c Context) (V, bool) {
+		if err := ctx.Err(); err != nil {
+			c.SetErr(err)
+			return *new(V), false
+		}
+		v, ok := iter.Next()
+		if !ok {
+			c.SetErr(iter.Err())
+			return *new(V), false
+		}
+		if err := ctx.Err(); err != nil {
+			c.SetErr(err)
+			return *new(V), false
+		}
+		return v, true
+	}, closer(iter))
+}
+
+// 値を変換したイテレータを返す。context.Context が終了したら停止する。
+func MapContext[V1 any, V2 any](ctx context.Context, iter Iter[V1], f func(context.Context, V1) (V2, error)) Iter[V2] {
+	return WithContext(ctx, Map(WithContext(ctx, iter), func(v V1) (V2, error) {
+		return f(ctx, v)
+	}))
+}
+
+// 条件を満たす値だけのイテレータを返す。context.Context が終了したら停止する。
+func FilterByContext[V any](ctx context.Context, iter Iter[V], f func(context.Context, V) (bool, error)) Iter[V] {
+	return WithContext(ctx, FilterBy(WithContext(ctx, iter), func(v V) (bool, error) {
+		return f(ctx, v)
+	}))
+}
+
+// 条件を満たす値を変換したイテレータを返す。context.Context が終了したら停止する。
+func CollectContext[V1 any, V2 any](ctx context.Context, iter Iter[V1], f func(context.Context, V1) (V2, bool, error)) Iter[V2] {
+	return WithContext(ctx, Collect(WithContext(ctx, iter), func(v V1) (V2, bool, error) {
+		return f(ctx, v)
+	}))
+}
+
+// 値をイテレータに変換し、それらを結合したイテレータを返す。context.Context が終了したら停止する。
+func FlatMapContext[V1 any, V2 any](ctx context.Context, iter Iter[V1], f func(context.Context, V1) (Iter[V2], error)) Iter[V2] {
+	return WithContext(ctx, FlatMap(WithContext(ctx, iter), func(v V1) (Iter[V2], error) {
+		sub, err := f(ctx, v)
+		if err != nil {
+			return nil, err
+		}
+		return WithContext(ctx, sub), nil
+	}))
+}
+
+// 値ごとに関数を実行する。context.Context が終了したら停止する。
+func ForEachContext[V any](ctx context.Context, iter Iter[V], f func(context.Context, V) error) error {
+	return ForEach(WithContext(ctx, iter), func(v V) error {
+		if err := f(ctx, v); err != nil {
+			return err
+		}
+		return ctx.Err()
+	})
+}
+
+// 値ごとに関数を実行する。context.Context が終了したら停止する。実行中にエラーが起きた場合 panic する。
+func MustForEachContext[V any](ctx context.Context, iter Iter[V], f func(context.Context, V) error) {
+	must.Must0(ForEachContext(ctx, iter, f))
+}
+
+// 条件を満たす値を除いたイテレータを返す。context.Context が終了したら停止する。
+func FilterNotByContext[V any](ctx context.Context, iter Iter[V], f func(context.Context, V) (bool, error)) Iter[V] {
+	return WithContext(ctx, FilterNotBy(WithContext(ctx, iter), func(v V) (bool, error) {
+		return f(ctx, v)
+	}))
+}
+
+// 条件を満たし続ける先頭の値のイテレータを返す。context.Context が終了したら停止する。
+func TakeWhileByContext[V any](ctx context.Context, iter Iter[V], f func(context.Context, V) (bool, error)) Iter[V] {
+	return WithContext(ctx, TakeWhileBy(WithContext(ctx, iter), func(v V) (bool, error) {
+		return f(ctx, v)
+	}))
+}
+
+// 条件を満たし続ける先頭の値を除いたイテレータを返す。context.Context が終了したら停止する。
+func DropWhileByContext[V any](ctx context.Context, iter Iter[V], f func(context.Context, V) (bool, error)) Iter[V] {
+	return WithContext(ctx, DropWhileBy(WithContext(ctx, iter), func(v V) (bool, error) {
+		return f(ctx, v)
+	}))
+}
+
+// 関数の返すキーが重複する値を除いたイテレータを返す。context.Context が終了したら停止する。
+func DistinctByContext[V any, K comparable](ctx context.Context, iter Iter[V], f func(context.Context, V) (K, error)) Iter[V] {
+	return WithContext(ctx, DistinctBy(WithContext(ctx, iter), func(v V) (K, error) {
+		return f(ctx, v)
+	}))
+}
+
+// 初期値と値を順に演算し、途中の結果を並べたイテレータを返す。context.Context が終了したら停止する。
+func ScanContext[V1 any, V2 any](ctx context.Context, iter Iter[V1], v V2, f func(context.Context, V2, V1) (V2, error)) Iter[V2] {
+	return WithContext(ctx, Scan(WithContext(ctx, iter), v, func(v2 V2, v1 V1) (V2, error) {
+		return f(ctx, v2, v1)
+	}))
+}
+
+// 状態を持ちながら値を変換したイテレータを返す。context.Context が終了したら停止する。
+// flush は nil でもよい。
+func MapWithStateContext[V1 any, S any, V2 any](ctx context.Context, iter Iter[V1], state S, f func(context.Context, S, V1) (S, []V2, error), flush func(context.Context, S) ([]V2, error)) Iter[V2] {
+	var flush1 func(S) ([]V2, error)
+	if flush != nil {
+		flush1 = func(state S) ([]V2, error) {
+			return flush(ctx, state)
+		}
+	}
+	return WithContext(ctx, MapWithState(WithContext(ctx, iter), state, func(state S, v V1) (S, []V2, error) {
+		return f(ctx, state, v)
+	}, flush1))
+}
+
+// 条件を満たす値の直前で分割したふたつのイテレータを返す。context.Context が終了したら停止する。
+func SplitByContext[V any](ctx context.Context, iter Iter[V], f func(context.Context, V) (bool, error)) (Iter[V], Iter[V], error) {
+	return SplitBy(WithContext(ctx, iter), func(v V) (bool, error) {
+		return f(ctx, v)
+	})
+}
+
+// 条件を満たす値の直前で分割したふたつのイテレータを返す。context.Context が終了したら停止する。実行中にエラーが起きた場合 panic する。
+func MustSplitByContext[V any](ctx context.Context, iter Iter[V], f func(context.Context, V) (bool, error)) (Iter[V], Iter[V]) {
+	return must.Must2(SplitByContext(ctx, iter, f))
+}
+
+// 条件を満たす値の直後で分割したふたつのイテレータを返す。context.Context が終了したら停止する。
+func SplitAfterByContext[V any](ctx context.Context, iter Iter[V], f func(context.Context, V) (bool, error)) (Iter[V], Iter[V], error) {
+	return SplitAfterBy(WithContext(ctx, iter), func(v V) (bool, error) {
+		return f(ctx, v)
+	})
+}
+
+// 条件を満たす値の直後で分割したふたつのイテレータを返す。context.Context が終了したら停止する。実行中にエラーが起きた場合 panic する。
+func MustSplitAfterByContext[V any](ctx context.Context, iter Iter[V], f func(context.Context, V) (bool, error)) (Iter[V], Iter[V]) {
+	return must.Must2(SplitAfterByContext(ctx, iter, f))
+}
+
+// 条件を満たし続ける先頭部分と残りの部分、ふたつのイテレータを返す。context.Context が終了したら停止する。
+func SpanByContext[V any](ctx context.Context, iter Iter[V], f func(context.Context, V) (bool, error)) (Iter[V], Iter[V], error) {
+	return SpanBy(WithContext(ctx, iter), func(v V) (bool, error) {
+		return f(ctx, v)
+	})
+}
+
+// 条件を満たし続ける先頭部分と残りの部分、ふたつのイテレータを返す。context.Context が終了したら停止する。実行中にエラーが起きた場合 panic する。
+func MustSpanByContext[V any](ctx context.Context, iter Iter[V], f func(context.Context, V) (bool, error)) (Iter[V], Iter[V]) {
+	return must.Must2(SpanByContext(ctx, iter, f))
+}
+
+// 条件を満たすイテレータと満たさないイテレータを返す。context.Context が終了したら停止する。
+// iter は閉じられるので、読み残した値はあとから読めない。
+func PartitionByContext[V any](ctx context.Context, iter Iter[V], f func(context.Context, V) (bool, error)) (Iter[V], Iter[V], error) {
+	return PartitionBy(WithContext(ctx, iter), func(v V) (bool, error) {
+		return f(ctx, v)
+	})
+}
+
+// 条件を満たすイテレータと満たさないイテレータを返す。context.Context が終了したら停止する。実行中にエラーが起きた場合 panic する。
+func MustPartitionByContext[V any](ctx context.Context, iter Iter[V], f func(context.Context, V) (bool, error)) (Iter[V], Iter[V]) {
+	return must.Must2(PartitionByContext(ctx, iter, f))
+}
+
+// 他のイテレータと関数で比較し、一致していたらtrueを返す。context.Context が終了したら停止する。
+// iter1 と iter2 は閉じられるので、読み残した値はあとから読めない。
+func EqualByContext[V any](ctx context.Context, iter1 Iter[V], iter2 Iter[V], f func(context.Context, V, V) (bool, error)) (bool, error) {
+	return EqualBy(WithContext(ctx, iter1), WithContext(ctx, iter2), func(v1 V, v2 V) (bool, error) {
+		return f(ctx, v1, v2)
+	})
+}
+
+// 他のイテレータと関数で比較し、一致していたらtrueを返す。context.Context が終了したら停止する。実行中にエラーが起きた場合 panic する。
+func MustEqualByContext[V any](ctx context.Context, iter1 Iter[V], iter2 Iter[V], f func(context.Context, V, V) (bool, error)) bool {
+	return must.Must1(EqualByContext(ctx, iter1, iter2, f))
+}
+
+// 条件を満たす値の数を返す。context.Context が終了したら停止する。
+// iter は閉じられるので、読み残した値はあとから読めない。
+func CountByContext[V any](ctx context.Context, iter Iter[V], f func(context.Context, V) (bool, error)) (int, error) {
+	return Len(FilterByContext(ctx, iter, f))
+}
+
+// 条件を満たす値の数を返す。context.Context が終了したら停止する。実行中にエラーが起きた場合 panic する。
+func MustCountByContext[V any](ctx context.Context, iter Iter[V], f func(context.Context, V) (bool, error)) int {
+	return must.Must1(CountByContext(ctx, iter, f))
+}
+
+// 値を順に演算する。context.Context が終了したら停止する。
+// iter は閉じられるので、読み残した値はあとから読めない。
+func ReduceContext[V any](ctx context.Context, iter Iter[V], f func(context.Context, V, V) (V, error)) (V, error) {
+	return Reduce(WithContext(ctx, iter), func(v1 V, v2 V) (V, error) {
+		return f(ctx, v1, v2)
+	})
+}
+
+// 値を順に演算する。context.Context が終了したら停止する。実行中にエラーが起きた場合 panic する。
+func MustReduceContext[V any](ctx context.Context, iter Iter[V], f func(context.Context, V, V) (V, error)) V {
+	return must.Must1(ReduceContext(ctx, iter, f))
+}
+
+// 初期値と値を順に演算する。context.Context が終了したら停止する。
+// iter は閉じられるので、読み残した値はあとから読めない。
+func FoldContext[V1 any, V2 any](ctx context.Context, iter Iter[V1], v V2, f func(context.Context, V2, V1) (V2, error)) (V2, error) {
+	return Fold(WithContext(ctx, iter), v, func(v2 V2, v1 V1) (V2, error) {
+		return f(ctx, v2, v1)
+	})
+}
+
+// 初期値と値を順に演算する。context.Context が終了したら停止する。実行中にエラーが起きた場合 panic する。
+func MustFoldContext[V1 any, V2 any](ctx context.Context, iter Iter[V1], v V2, f func(context.Context, V2, V1) (V2, error)) V2 {
+	return must.Must1(FoldContext(ctx, iter, v, f))
+}
+
+// 値を変換して合計を演算する。context.Context が終了したら停止する。
+// iter は閉じられるので、読み残した値はあとから読めない。
+func SumByContext[V1 any, V2 constraints.Ordered | constraints.Complex](ctx context.Context, iter Iter[V1], f func(context.Context, V1) (V2, error)) (V2, error) {
+	return Sum(MapContext(ctx, iter, f))
+}
+
+// 値を変換して合計を演算する。context.Context が終了したら停止する。実行中にエラーが起きた場合 panic する。
+func MustSumByContext[V1 any, V2 constraints.Ordered | constraints.Complex](ctx context.Context, iter Iter[V1], f func(context.Context, V1) (V2, error)) V2 {
+	return must.Must1(SumByContext(ctx, iter, f))
+}
+
+// 値を変換して最大の値を返す。context.Context が終了したら停止する。
+// iter は閉じられるので、読み残した値はあとから読めない。
+func MaxByContext[V1 any, V2 constraints.Ordered](ctx context.Context, iter Iter[V1], f func(context.Context, V1) (V2, error)) (V2, error) {
+	return Max(MapContext(ctx, iter, f))
+}
+
+// 値を変換して最大の値を返す。context.Context が終了したら停止する。実行中にエラーが起きた場合 panic する。
+func MustMaxByContext[V1 any, V2 constraints.Ordered](ctx context.Context, iter Iter[V1], f func(context.Context, V1) (V2, error)) V2 {
+	return must.Must1(MaxByContext(ctx, iter, f))
+}
+
+// 値を変換して最小の値を返す。context.Context が終了したら停止する。
+// iter は閉じられるので、読み残した値はあとから読めない。
+func MinByContext[V1 any, V2 constraints.Ordered](ctx context.Context, iter Iter[V1], f func(context.Context, V1) (V2, error)) (V2, error) {
+	return Min(MapContext(ctx, iter, f))
+}
+
+// 値を変換して最小の値を返す。context.Context が終了したら停止する。実行中にエラーが起きた場合 panic する。
+func MustMinByContext[V1 any, V2 constraints.Ordered](ctx context.Context, iter Iter[V1], f func(context.Context, V1) (V2, error)) V2 {
+	return must.Must1(MinByContext(ctx, iter, f))
+}
+
+// 条件を満たす最初の値の位置を返す。context.Context が終了したら停止する。
+// iter は閉じられるので、読み残した値はあとから読めない。
+func IndexByContext[V any](ctx context.Context, iter Iter[V], f func(context.Context, V) (bool, error)) (int, error) {
+	return IndexBy(WithContext(ctx, iter), func(v V) (bool, error) {
+		return f(ctx, v)
+	})
+}
+
+// 条件を満たす最初の値の位置を返す。context.Context が終了したら停止する。実行中にエラーが起きた場合 panic する。
+func MustIndexByContext[V any](ctx context.Context, iter Iter[V], f func(context.Context, V) (bool, error)) int {
+	return must.Must1(IndexByContext(ctx, iter, f))
+}
+
+// 条件を満たす最後の値の位置を返す。context.Context が終了したら停止する。
+// iter は閉じられるので、読み残した値はあとから読めない。
+func LastIndexByContext[V any](ctx context.Context, iter Iter[V], f func(context.Context, V) (bool, error)) (int, error) {
+	return LastIndexBy(WithContext(ctx, iter), func(v V) (bool, error) {
+		return f(ctx, v)
+	})
+}
+
+// 条件を満たす最後の値の位置を返す。context.Context が終了したら停止する。実行中にエラーが起きた場合 panic する。
+func MustLastIndexByContext[V any](ctx context.Context, iter Iter[V], f func(context.Context, V) (bool, error)) int {
+	return must.Must1(LastIndexByContext(ctx, iter, f))
+}
+
+// 条件を満たす値を返す。context.Context が終了したら停止する。
+// iter は閉じられるので、読み残した値はあとから読めない。
+func FindByContext[V any](ctx context.Context, iter Iter[V], f func(context.Context, V) (bool, error)) (V, bool, error) {
+	return FindBy(WithContext(ctx, iter), func(v V) (bool, error) {
+		return f(ctx, v)
+	})
+}
+
+// 条件を満たす値を返す。context.Context が終了したら停止する。実行中にエラーが起きた場合 panic する。
+func MustFindByContext[V any](ctx context.Context, iter Iter[V], f func(context.Context, V) (bool, error)) (V, bool) {
+	return must.Must2(FindByContext(ctx, iter, f))
+}
+
+// 条件を満たす値が存在したらtrueを返す。context.Context が終了したら停止する。
+// iter は閉じられるので、読み残した値はあとから読めない。
+func ExistsByContext[V any](ctx context.Context, iter Iter[V], f func(context.Context, V) (bool, error)) (bool, error) {
+	return ExistsBy(WithContext(ctx, iter), func(v V) (bool, error) {
+		return f(ctx, v)
+	})
+}
+
+// 条件を満たす値が存在したらtrueを返す。context.Context が終了したら停止する。実行中にエラーが起きた場合 panic する。
+func MustExistsByContext[V any](ctx context.Context, iter Iter[V], f func(context.Context, V) (bool, error)) bool {
+	return must.Must1(ExistsByContext(ctx, iter, f))
+}
+
+// すべての値が条件を満たせばtrueを返す。context.Context が終了したら停止する。
+// iter は閉じられるので、読み残した値はあとから読めない。
+func ForAllByContext[V any](ctx context.Context, iter Iter[V], f func(context.Context, V) (bool, error)) (bool, error) {
+	return ForAllBy(WithContext(ctx, iter), func(v V) (bool, error) {
+		return f(ctx, v)
+	})
+}
+
+// すべての値が条件を満たせばtrueを返す。context.Context が終了したら停止する。実行中にエラーが起きた場合 panic する。
+func MustForAllByContext[V any](ctx context.Context, iter Iter[V], f func(context.Context, V) (bool, error)) bool {
+	return must.Must1(ForAllByContext(ctx, iter, f))
+}
+
+// 値ごとに関数の返すキーでグルーピングしたマップを返す。context.Context が終了したら停止する。
+// iter は閉じられるので、読み残した値はあとから読めない。
+func GroupByContext[K comparable, V any](ctx context.Context, iter Iter[V], f func(context.Context, V) (K, error)) (map[K][]V, error) {
+	return GroupBy(WithContext(ctx, iter), func(v V) (K, error) {
+		return f(ctx, v)
+	})
+}
+
+// 値ごとに関数の返すキーでグルーピングしたマップを返す。context.Context が終了したら停止する。実行中にエラーが起きた場合 panic する。
+func MustGroupByContext[K comparable, V any](ctx context.Context, iter Iter[V], f func(context.Context, V) (K, error)) map[K][]V {
+	return must.Must1(GroupByContext(ctx, iter, f))
+}
@@ -0,0 +1,82 @@
+package iter
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	iter := WithContext(ctx, Range(0, 10, 1))
+	for i := 0; i < 3; i++ {
+		if _, ok := iter.Next(); !ok {
+			t.Fatal(iter.Err())
+		}
+	}
+	cancel()
+	if _, ok := iter.Next(); ok {
+		t.Fatal("Next() returned a value after cancel")
+	}
+	if !errors.Is(iter.Err(), context.Canceled) {
+		t.Fatalf("err = %v, want %v", iter.Err(), context.Canceled)
+	}
+}
+
+// 3 番目の値で ctx をキャンセルする関数を渡して、各段が context.Canceled で止まることを確かめる。
+func TestContextStages(t *testing.T) {
+	stages := map[string]func(ctx context.Context, iter Iter[int], f func(context.Context, int) error) Iter[int]{
+		"MapContext": func(ctx context.Context, iter Iter[int], f func(context.Context, int) error) Iter[int] {
+			return MapContext(ctx, iter, func(ctx context.Context, v int) (int, error) { return v, f(ctx, v) })
+		},
+		"FilterByContext": func(ctx context.Context, iter Iter[int], f func(context.Context, int) error) Iter[int] {
+			return FilterByContext(ctx, iter, func(ctx context.Context, v int) (bool, error) { return true, f(ctx, v) })
+		},
+		"FilterNotByContext": func(ctx context.Context, iter Iter[int], f func(context.Context, int) error) Iter[int] {
+			return FilterNotByContext(ctx, iter, func(ctx context.Context, v int) (bool, error) { return false, f(ctx, v) })
+		},
+		"TakeWhileByContext": func(ctx context.Context, iter Iter[int], f func(context.Context, int) error) Iter[int] {
+			return TakeWhileByContext(ctx, iter, func(ctx context.Context, v int) (bool, error) { return true, f(ctx, v) })
+		},
+		"DistinctByContext": func(ctx context.Context, iter Iter[int], f func(context.Context, int) error) Iter[int] {
+			return DistinctByContext(ctx, iter, func(ctx context.Context, v int) (int, error) { return v, f(ctx, v) })
+		},
+		"ScanContext": func(ctx context.Context, iter Iter[int], f func(context.Context, int) error) Iter[int] {
+			return ScanContext(ctx, iter, 0, func(ctx context.Context, sum int, v int) (int, error) { return sum + v, f(ctx, v) })
+		},
+		"MapWithStateContext": func(ctx context.Context, iter Iter[int], f func(context.Context, int) error) Iter[int] {
+			return MapWithStateContext(ctx, iter, 0, func(ctx context.Context, s int, v int) (int, []int, error) {
+				return s, []int{v}, f(ctx, v)
+			}, nil)
+		},
+	}
+	for name, stage := range stages {
+		ctx, cancel := context.WithCancel(context.Background())
+		iter := stage(ctx, Range(0, 10, 1), func(ctx context.Context, v int) error {
+			if v == 2 {
+				cancel()
+			}
+			return nil
+		})
+		got, err := ToSlice(iter)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: got %v, %v, want %v", name, got, err, context.Canceled)
+		}
+		if len(got) > 2 {
+			t.Errorf("%s: got %v after cancel", name, got)
+		}
+	}
+}
+
+func TestFoldContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := FoldContext(ctx, Range(0, 10, 1), 0, func(ctx context.Context, sum int, v int) (int, error) {
+		if v == 2 {
+			cancel()
+		}
+		return sum + v, nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want %v", err, context.Canceled)
+	}
+}
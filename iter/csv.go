@@ -0,0 +1,320 @@
+package iter
+
+import (
+	"encoding"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSV の値を変換できなかったときのエラー。
+type CSVError struct {
+	Row    int    // 行番号（1 始まり）
+	Column int    // 列番号（1 始まり）
+	Name   string // 列名
+	Err    error
+}
+
+func (e *CSVError) Error() string {
+	return fmt.Sprintf("iter: csv row %d, column %d (%s): %v", e.Row, e.Column, e.Name, e.Err)
+}
+
+func (e *CSVError) Unwrap() error {
+	return e.Err
+}
+
+// csv.Reader から構造体のイテレータをつくる。
+// 最初の行をヘッダーとして読み、列名とフィールドを `csv:"name"` タグで対応づける。
+// タグの無いフィールドはフィールド名で対応づけ、`csv:"-"` のフィールドは無視する。
+// time.Time のフィールドは `csv:"name,layout=2006-01-02"` のようにレイアウトを指定できる（既定は RFC3339）。
+// ポインタと opt.Option のフィールドは、空の値のとき nil や None になる。
+func FromCSVAs[T any](r *csv.Reader) Iter[T] {
+	return FromCSVAsWithPolicy[T](r, nil)
+}
+
+// csv.Reader から構造体のイテレータをつくる。列名とフィールドの対応は FromCSVAs と同じ。
+// 列の数が合わない行や値を変換できない行のエラーは、その行とともに policy に渡す。
+// policy が nil の場合は StopOnError() を使う。
+func FromCSVAsWithPolicy[T any](r *csv.Reader, policy ErrorPolicy[[]string]) Iter[T] {
+	if policy == nil {
+		policy = StopOnError[[]string]()
+	}
+	var columns []*csvField
+	started := false
+	return &customIter[T]{
+		next: func(ctx Context) (T, bool) {
+			if !started {
+				started = true
+				fields, err := csvFields(reflect.TypeOf(*new(T)))
+				if err != nil {
+					ctx.SetErr(err)
+					return *new(T), false
+				}
+				header, err := r.Read()
+				if err != nil {
+					if err != io.EOF {
+						ctx.SetErr(err)
+					}
+					return *new(T), false
+				}
+				columns = make([]*csvField, len(header))
+				for i, name := range header {
+					for j := range fields {
+						if fields[j].name == name {
+							columns[i] = &fields[j]
+							break
+						}
+					}
+				}
+			}
+
+			for {
+				record, err := r.Read()
+				if err == nil {
+					var v T
+					v, err = decodeCSVRecord[T](r, columns, record)
+					if err == nil {
+						return v, true
+					}
+				}
+				if err == io.EOF {
+					return *new(T), false
+				}
+				var perr *csv.ParseError
+				var cerr *CSVError
+				if !errors.As(err, &perr) && !errors.As(err, &cerr) {
+					ctx.SetErr(err)
+					return *new(T), false
+				}
+				if err := policy(record, err); err != nil {
+					ctx.SetErr(err)
+					return *new(T), false
+				}
+			}
+		},
+	}
+}
+
+func decodeCSVRecord[T any](r *csv.Reader, columns []*csvField, record []string) (T, error) {
+	v := *new(T)
+	rv := reflect.ValueOf(&v).Elem()
+	for i, s := range record {
+		if i >= len(columns) || columns[i] == nil {
+			continue
+		}
+		if err := decodeCSVValue(rv.FieldByIndex(columns[i].index), s, columns[i].layout); err != nil {
+			row, _ := r.FieldPos(i)
+			return *new(T), &CSVError{Row: row, Column: i + 1, Name: columns[i].name, Err: err}
+		}
+	}
+	return v, nil
+}
+
+// 構造体のイテレータを、ヘッダーをつけて csv.Writer に書き込む。
+// 列名とフィールドの対応は FromCSVAs と同じ。
+// iter は閉じられるので、読み残した値はあとから読めない。
+func WriteCSV[T any](iter Iter[T], w *csv.Writer) error {
+	defer Close(iter)
+	fields, err := csvFields(reflect.TypeOf(*new(T)))
+	if err != nil {
+		return err
+	}
+	record := make([]string, len(fields))
+	for i := range fields {
+		record[i] = fields[i].name
+	}
+	if err := w.Write(record); err != nil {
+		return err
+	}
+	for {
+		v, ok := iter.Next()
+		if !ok {
+			if err := iter.Err(); err != nil {
+				return err
+			}
+			break
+		}
+		rv := reflect.ValueOf(v)
+		for i := range fields {
+			s, err := encodeCSVValue(rv.FieldByIndex(fields[i].index), fields[i].layout)
+			if err != nil {
+				return fmt.Errorf("iter: csv column %s: %w", fields[i].name, err)
+			}
+			record[i] = s
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+type csvField struct {
+	index  []int
+	name   string
+	layout string
+}
+
+func csvFields(t reflect.Type) ([]csvField, error) {
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("iter: csv: %v is not a struct", t)
+	}
+	fields := []csvField{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, ok := f.Tag.Lookup("csv")
+		if tag == "-" {
+			continue
+		}
+		if f.Anonymous && !ok && f.Type.Kind() == reflect.Struct {
+			sub, err := csvFields(f.Type)
+			if err != nil {
+				return nil, err
+			}
+			for _, field := range sub {
+				field.index = append([]int{i}, field.index...)
+				fields = append(fields, field)
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		field := csvField{index: []int{i}, name: f.Name, layout: time.RFC3339}
+		parts := strings.Split(tag, ",")
+		if parts[0] != "" {
+			field.name = parts[0]
+		}
+		for _, part := range parts[1:] {
+			if strings.HasPrefix(part, "layout=") {
+				field.layout = strings.TrimPrefix(part, "layout=")
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	// opt.Option は iter に依存しているため、メソッドで判別する。
+	optionType = reflect.TypeOf((*interface{ IsDefined() bool })(nil)).Elem()
+)
+
+func decodeCSVValue(v reflect.Value, s string, layout string) error {
+	t := v.Type()
+	switch {
+	case t == timeType:
+		tm, err := time.Parse(layout, s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(tm))
+		return nil
+
+	case t.Kind() == reflect.Pointer:
+		if s == "" {
+			v.Set(reflect.Zero(t))
+			return nil
+		}
+		p := reflect.New(t.Elem())
+		if err := decodeCSVValue(p.Elem(), s, layout); err != nil {
+			return err
+		}
+		v.Set(p)
+		return nil
+
+	case t.Kind() == reflect.Slice && t.Implements(optionType):
+		if s == "" {
+			v.Set(reflect.Zero(t))
+			return nil
+		}
+		o := reflect.MakeSlice(t, 1, 1)
+		if err := decodeCSVValue(o.Index(0), s, layout); err != nil {
+			return err
+		}
+		v.Set(o)
+		return nil
+
+	case reflect.PointerTo(t).Implements(textUnmarshalerType):
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, t.Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, t.Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, t.Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return errors.New("unsupported type " + t.String())
+	}
+	return nil
+}
+
+func encodeCSVValue(v reflect.Value, layout string) (string, error) {
+	t := v.Type()
+	switch {
+	case t == timeType:
+		return v.Interface().(time.Time).Format(layout), nil
+
+	case t.Kind() == reflect.Pointer:
+		if v.IsNil() {
+			return "", nil
+		}
+		return encodeCSVValue(v.Elem(), layout)
+
+	case t.Kind() == reflect.Slice && t.Implements(optionType):
+		if v.Len() == 0 {
+			return "", nil
+		}
+		return encodeCSVValue(v.Index(0), layout)
+
+	case t.Implements(textMarshalerType):
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, t.Bits()), nil
+	}
+	return "", errors.New("unsupported type " + t.String())
+}
@@ -0,0 +1,172 @@
+package iter
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"time"
+)
+
+// 直近n個の値の中で、関数の返すキーが重複する値を除いたイテレータを返す。
+// 記憶するキーは直近n個だけなので、離れた位置にある重複は除かれない。
+func DistinctWindow[V any, K comparable](iter Iter[V], f func(V) (K, error), n int) Iter[V] {
+	if n < 1 {
+		n = 1
+	}
+	ring := make([]K, 0, n) // 直近のキー
+	head := 0
+	counts := map[K]int{} // ring に含まれるキーの数
+	return FromFuncWithClose(func(ctx Context) (V, bool) {
+		for {
+			v, ok := iter.Next()
+			if !ok {
+				ctx.SetErr(iter.Err())
+				return *new(V), false
+			}
+
+			k, err := f(v)
+			if err != nil {
+				ctx.SetErr(err)
+				return *new(V), false
+			}
+			seen := counts[k] > 0
+
+			if len(ring) < n {
+				ring = append(ring, k)
+			} else {
+				old := ring[head]
+				if counts[old]--; counts[old] <= 0 {
+					delete(counts, old)
+				}
+				ring[head] = k
+				head = (head + 1) % n
+			}
+			counts[k]++
+
+			if seen {
+				continue
+			}
+			return v, true
+		}
+	}, closer(iter))
+}
+
+// 直前の期間dの中で、関数の返すキーが重複する値を除いたイテレータを返す。
+// 時刻は値を読んだときの time.Now() で、期間を過ぎたキーは忘れられる。
+func DistinctWithin[V any, K comparable](iter Iter[V], f func(V) (K, error), d time.Duration) Iter[V] {
+	queue := []distinctEntry[K]{} // 読んだ順のキー
+	last := map[K]time.Time{}     // キーを最後に読んだ時刻
+	return FromFuncWithClose(func(ctx Context) (V, bool) {
+		for {
+			v, ok := iter.Next()
+			if !ok {
+				ctx.SetErr(iter.Err())
+				return *new(V), false
+			}
+
+			k, err := f(v)
+			if err != nil {
+				ctx.SetErr(err)
+				return *new(V), false
+			}
+
+			now := time.Now()
+			for len(queue) > 0 && now.Sub(queue[0].at) > d {
+				if at, ok := last[queue[0].key]; ok && at.Equal(queue[0].at) {
+					delete(last, queue[0].key)
+				}
+				queue[0] = distinctEntry[K]{}
+				queue = queue[1:]
+			}
+
+			_, seen := last[k]
+			last[k] = now
+			queue = append(queue, distinctEntry[K]{key: k, at: now})
+
+			if seen {
+				continue
+			}
+			return v, true
+		}
+	}, closer(iter))
+}
+
+type distinctEntry[K comparable] struct {
+	key K
+	at  time.Time
+}
+
+// 関数の返すキーが重複する値を、ブルームフィルタを使って近似的に除いたイテレータを返す。
+// 値の数が n 以下の場合、重複していない値を誤って除く確率はおよそ fpRate になる。
+// 使うメモリは n と fpRate だけで決まる。
+func ApproxDistinctBy[V any](iter Iter[V], f func(V) ([]byte, error), n int, fpRate float64) Iter[V] {
+	filter := newBloomFilter(n, fpRate)
+	return FromFuncWithClose(func(ctx Context) (V, bool) {
+		for {
+			v, ok := iter.Next()
+			if !ok {
+				ctx.SetErr(iter.Err())
+				return *new(V), false
+			}
+
+			k, err := f(v)
+			if err != nil {
+				ctx.SetErr(err)
+				return *new(V), false
+			}
+			if filter.testAndAdd(k) {
+				continue
+			}
+			return v, true
+		}
+	}, closer(iter))
+}
+
+type bloomFilter struct {
+	bits []uint64
+	m    uint64 // ビット数
+	k    int    // ハッシュ関数の数
+}
+
+func newBloomFilter(n int, fpRate float64) *bloomFilter {
+	if n < 1 {
+		n = 1
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.01
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	if m < 64 {
+		m = 64
+	}
+	k := int(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &bloomFilter{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}
+}
+
+// キーが含まれている可能性があれば true を返し、キーを追加する。
+func (b *bloomFilter) testAndAdd(key []byte) bool {
+	h := fnv.New128a()
+	_, _ = h.Write(key)
+	sum := h.Sum(nil)
+	h1 := binary.BigEndian.Uint64(sum[:8])
+	// h2 が 0 だとすべてのハッシュが同じ位置になるので、奇数にする。
+	h2 := binary.BigEndian.Uint64(sum[8:]) | 1
+
+	found := true
+	for i := 0; i < b.k; i++ {
+		pos := (h1 + uint64(i)*h2) % b.m
+		word, bit := pos/64, uint64(1)<<(pos%64)
+		if b.bits[word]&bit == 0 {
+			found = false
+			b.bits[word] |= bit
+		}
+	}
+	return found
+}
@@ -4,10 +4,13 @@ import (
 	"bufio"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"io/fs"
 	"os"
 	"reflect"
+	"strings"
 
 	"github.com/thamaji/gu/tuple"
 	"golang.org/x/exp/constraints"
@@ -153,6 +156,7 @@ func FromBytesScanner(scanner *bufio.Scanner) Iter[[]byte] {
 }
 
 // json.Decoder からイテレータをつくる。
+// JSON Lines のように連続した値を順に読む。
 func FromJSON[V any](decoder *json.Decoder) Iter[V] {
 	more := decoder.More()
 	return &customIter[V]{
@@ -171,6 +175,99 @@ func FromJSON[V any](decoder *json.Decoder) Iter[V] {
 	}
 }
 
+// json.Decoder から、トップレベルの配列の要素を順に読むイテレータをつくる。
+// 配列全体をメモリに読み込むことはない。
+func FromJSONArray[V any](decoder *json.Decoder) Iter[V] {
+	return FromJSONArrayAt[V](decoder, "")
+}
+
+// json.Decoder から、path にある配列の要素を順に読むイテレータをつくる。
+// path はオブジェクトのキーを "." でつないだもので、".items" や ".data.items" のように指定する。
+// path が空または "." の場合はトップレベルの配列を読む。
+// 配列全体をメモリに読み込むことはない。
+func FromJSONArrayAt[V any](decoder *json.Decoder, path string) Iter[V] {
+	started := false
+	return &customIter[V]{
+		next: func(ctx Context) (V, bool) {
+			if !started {
+				started = true
+				if err := seekJSONArray(decoder, path); err != nil {
+					ctx.SetErr(err)
+					return *new(V), false
+				}
+			}
+			if !decoder.More() {
+				return *new(V), false
+			}
+			v := *new(V)
+			if err := decoder.Decode(&v); err != nil {
+				ctx.SetErr(err)
+				return v, false
+			}
+			return v, true
+		},
+	}
+}
+
+// path にある配列の開始位置まで読み進める。
+func seekJSONArray(decoder *json.Decoder, path string) error {
+	for _, key := range strings.Split(strings.Trim(path, "."), ".") {
+		if key == "" {
+			continue
+		}
+		if err := expectJSONDelim(decoder, '{', path); err != nil {
+			return err
+		}
+		for {
+			if !decoder.More() {
+				return errors.New("iter: json path not found: " + path)
+			}
+			t, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+			if t == key {
+				break
+			}
+			if err := skipJSONValue(decoder); err != nil {
+				return err
+			}
+		}
+	}
+	return expectJSONDelim(decoder, '[', path)
+}
+
+func expectJSONDelim(decoder *json.Decoder, delim json.Delim, path string) error {
+	t, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if t != delim {
+		return fmt.Errorf("iter: json path %q: expected %v, got %v", path, delim, t)
+	}
+	return nil
+}
+
+// 値をひとつ読み飛ばす。
+func skipJSONValue(decoder *json.Decoder) error {
+	depth := 0
+	for {
+		t, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch t {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
 // csv.Reader からイテレータをつくる。
 func FromCSV(r *csv.Reader) Iter[[]string] {
 	return &customIter[[]string]{
@@ -202,14 +299,14 @@ func FromDir(dirname string) Iter[fs.FileInfo] {
 		next: func(ctx Context) (fs.FileInfo, bool) {
 			list, err := f.Readdir(1)
 			if err != nil {
-				_ = f.Close()
 				if err != io.EOF {
 					ctx.SetErr(err)
 				}
 				return nil, false
 			}
-			return list[0], false
+			return list[0], true
 		},
+		close: f.Close,
 	}
 }
 
@@ -228,14 +325,14 @@ func FromDirname(dirname string) Iter[string] {
 		next: func(ctx Context) (string, bool) {
 			list, err := f.Readdirnames(1)
 			if err != nil {
-				_ = f.Close()
 				if err != io.EOF {
 					ctx.SetErr(err)
 				}
 				return "", false
 			}
-			return list[0], false
+			return list[0], true
 		},
+		close: f.Close,
 	}
 }
 
@@ -246,21 +343,50 @@ func FromFunc[V any](f func(Context) (V, bool)) Iter[V] {
 	}
 }
 
+// 関数からイテレータをつくる。
+// イテレータを閉じたとき close が一度だけ実行される。
+func FromFuncWithClose[V any](f func(Context) (V, bool), close func() error) Iter[V] {
+	return &customIter[V]{
+		next:  f,
+		close: close,
+	}
+}
+
 type Context interface {
 	SetErr(error)
 	Err() error
 }
 
 type customIter[V any] struct {
-	next func(Context) (V, bool)
-	err  error
+	next   func(Context) (V, bool)
+	close  func() error
+	closed bool
+	err    error
 }
 
 func (iter *customIter[V]) Next() (V, bool) {
-	if iter.err != nil {
+	if iter.err != nil || iter.closed {
 		return *new(V), false
 	}
-	return iter.next(iter)
+	v, ok := iter.next(iter)
+	if !ok {
+		// 終端に達したら資源を解放する。
+		if err := iter.Close(); err != nil && iter.err == nil {
+			iter.err = err
+		}
+	}
+	return v, ok
+}
+
+func (iter *customIter[V]) Close() error {
+	if iter.closed {
+		return nil
+	}
+	iter.closed = true
+	if iter.close == nil {
+		return nil
+	}
+	return iter.close()
 }
 
 func (iter *customIter[V]) SetErr(err error) {
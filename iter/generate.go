@@ -0,0 +1,116 @@
+package iter
+
+import (
+	"context"
+	"time"
+)
+
+// seed, f(seed), f(f(seed)), ... と続く無限のイテレータをつくる。
+func Iterate[V any](seed V, f func(V) (V, error)) Iter[V] {
+	v := seed
+	started := false
+	return FromFunc(func(ctx Context) (V, bool) {
+		if started {
+			next, err := f(v)
+			if err != nil {
+				ctx.SetErr(err)
+				return *new(V), false
+			}
+			v = next
+		}
+		started = true
+		return v, true
+	})
+}
+
+// 状態から値と次の状態を求め続けるイテレータをつくる。
+// 関数が false を返したら終了する。
+func Unfold[S any, V any](state S, f func(S) (V, S, bool, error)) Iter[V] {
+	return FromFunc(func(ctx Context) (V, bool) {
+		v, next, ok, err := f(state)
+		if err != nil {
+			ctx.SetErr(err)
+			return *new(V), false
+		}
+		if !ok {
+			return *new(V), false
+		}
+		state = next
+		return v, true
+	})
+}
+
+// イテレータの値を繰り返す無限のイテレータをつくる。
+// 値は最初の一周で Cache に記憶され、二周目以降は記憶した値が返される。
+// 値が無い場合は終了する。
+func Cycle[V any](iter Iter[V]) Iter[V] {
+	c := Cache(iter, nil)
+	current := c.Iter()
+	empty := true
+	return FromFuncWithClose(func(ctx Context) (V, bool) {
+		for {
+			v, ok := current.Next()
+			if ok {
+				empty = false
+				return v, true
+			}
+			if err := current.Err(); err != nil {
+				ctx.SetErr(err)
+				return *new(V), false
+			}
+			if empty {
+				return *new(V), false
+			}
+			current = c.Iter()
+		}
+	}, c.Close)
+}
+
+// 値を繰り返す無限のイテレータをつくる。
+func Repeat[V any](v V) Iter[V] {
+	return IterFunc[V](func() (V, bool) {
+		return v, true
+	})
+}
+
+// 値をn回繰り返すイテレータをつくる。
+func RepeatN[V any](v V, n int) Iter[V] {
+	i := 0
+	return IterFunc[V](func() (V, bool) {
+		if i >= n {
+			return *new(V), false
+		}
+		i++
+		return v, true
+	})
+}
+
+// 関数の返す値を並べた無限のイテレータをつくる。
+func Generate[V any](f func() (V, error)) Iter[V] {
+	return FromFunc(func(ctx Context) (V, bool) {
+		v, err := f()
+		if err != nil {
+			ctx.SetErr(err)
+			return *new(V), false
+		}
+		return v, true
+	})
+}
+
+// 一定の間隔で時刻を返すイテレータをつくる。
+// Next は次の時刻まで待つ。ctx が終了した場合は停止し、Err は ctx.Err() を返す。
+func Ticker(ctx context.Context, interval time.Duration) Iter[time.Time] {
+	ticker := time.NewTicker(interval)
+	return FromFuncWithClose(func(c Context) (time.Time, bool) {
+		select {
+		case t := <-ticker.C:
+			return t, true
+		case <-ctx.Done():
+			c.SetErr(ctx.Err())
+			return time.Time{}, false
+		}
+	}, func() error {
+		ticker.Stop()
+		return nil
+	})
+}
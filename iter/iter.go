@@ -1,5 +1,7 @@
 package iter
 
+import "io"
+
 type Iter[V any] interface {
 	// 次の値を返す。
 	// 第２戻り値は値があるときは true、なければ false を返す。
@@ -22,3 +24,28 @@ func Empty[V any]() Iter[V] {
 		return *new(V), false
 	})
 }
+
+// イテレータを閉じる。
+// イテレータが io.Closer を実装していない場合は何もしない。
+func Close[V any](iter Iter[V]) error {
+	if c, ok := iter.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// イテレータをすべて閉じる関数を返す。
+// 引数には Iter を渡す。io.Closer を実装していないものは無視される。
+func closer(iters ...any) func() error {
+	return func() error {
+		var err error
+		for _, iter := range iters {
+			if c, ok := iter.(io.Closer); ok {
+				if err1 := c.Close(); err == nil {
+					err = err1
+				}
+			}
+		}
+		return err
+	}
+}
@@ -0,0 +1,202 @@
+package iter
+
+import (
+	"github.com/thamaji/gu/tuple"
+	"golang.org/x/exp/constraints"
+)
+
+// 関数の返すキーが一致する値をペアにしたイテレータを返す（内部結合）。
+// right は最初に値を読んだときに読み切られる。
+func InnerJoin[L any, R any, K comparable](left Iter[L], right Iter[R], lkey func(L) (K, error), rkey func(R) (K, error)) Iter[tuple.T2[L, R]] {
+	return Map(hashJoin(left, right, lkey, rkey, false, false), func(t tuple.T2[*L, *R]) (tuple.T2[L, R], error) {
+		return tuple.NewT2(*t.V1, *t.V2), nil
+	})
+}
+
+// 関数の返すキーが一致する値をペアにしたイテレータを返す（左外部結合）。
+// 一致する値が right に無い場合、ペアの右側は nil になる。
+// right は最初に値を読んだときに読み切られる。
+func LeftJoin[L any, R any, K comparable](left Iter[L], right Iter[R], lkey func(L) (K, error), rkey func(R) (K, error)) Iter[tuple.T2[L, *R]] {
+	return Map(hashJoin(left, right, lkey, rkey, true, false), func(t tuple.T2[*L, *R]) (tuple.T2[L, *R], error) {
+		return tuple.NewT2(*t.V1, t.V2), nil
+	})
+}
+
+// 関数の返すキーが一致する値をペアにしたイテレータを返す（右外部結合）。
+// 一致する値が left に無い場合、ペアの左側は nil になる。
+// left は最初に値を読んだときに読み切られる。
+func RightJoin[L any, R any, K comparable](left Iter[L], right Iter[R], lkey func(L) (K, error), rkey func(R) (K, error)) Iter[tuple.T2[*L, R]] {
+	return Map(hashJoin(right, left, rkey, lkey, true, false), func(t tuple.T2[*R, *L]) (tuple.T2[*L, R], error) {
+		return tuple.NewT2(t.V2, *t.V1), nil
+	})
+}
+
+// 関数の返すキーが一致する値をペアにしたイテレータを返す（完全外部結合）。
+// 一致する値が無い側は nil になる。right にだけある値は最後に返される。
+// right は最初に値を読んだときに読み切られる。
+func FullOuterJoin[L any, R any, K comparable](left Iter[L], right Iter[R], lkey func(L) (K, error), rkey func(R) (K, error)) Iter[tuple.T2[*L, *R]] {
+	return hashJoin(left, right, lkey, rkey, true, true)
+}
+
+// キーでソート済みのイテレータどうしで、関数の返すキーが一致する値をペアにしたイテレータを返す（内部結合）。
+// InnerJoin と異なり、同じキーの値だけをメモリに保持する。
+func MergeJoin[L any, R any, K constraints.Ordered](left Iter[L], right Iter[R], lkey func(L) (K, error), rkey func(R) (K, error)) Iter[tuple.T2[L, R]] {
+	var r R
+	var rk K
+	hasR, started := false, false
+	var group []R
+	var gk K
+	hasGroup := false
+	var pending []tuple.T2[L, R]
+
+	// right を次の値に進める。
+	advance := func(ctx Context) bool {
+		v, ok := right.Next()
+		if !ok {
+			hasR = false
+			if err := right.Err(); err != nil {
+				ctx.SetErr(err)
+				return false
+			}
+			return true
+		}
+		k, err := rkey(v)
+		if err != nil {
+			ctx.SetErr(err)
+			return false
+		}
+		r, rk, hasR = v, k, true
+		return true
+	}
+
+	return FromFuncWithClose(func(ctx Context) (tuple.T2[L, R], bool) {
+		if !started {
+			started = true
+			if !advance(ctx) {
+				return tuple.T2[L, R]{}, false
+			}
+		}
+		for len(pending) == 0 {
+			l, ok := left.Next()
+			if !ok {
+				ctx.SetErr(left.Err())
+				return tuple.T2[L, R]{}, false
+			}
+			lk, err := lkey(l)
+			if err != nil {
+				ctx.SetErr(err)
+				return tuple.T2[L, R]{}, false
+			}
+
+			if !hasGroup || gk != lk {
+				group, hasGroup = group[:0], false
+				for hasR && rk < lk {
+					if !advance(ctx) {
+						return tuple.T2[L, R]{}, false
+					}
+				}
+				for hasR && rk == lk {
+					group, gk, hasGroup = append(group, r), rk, true
+					if !advance(ctx) {
+						return tuple.T2[L, R]{}, false
+					}
+				}
+			}
+			if !hasGroup || gk != lk {
+				continue
+			}
+			for _, v := range group {
+				pending = append(pending, tuple.NewT2(l, v))
+			}
+		}
+		t := pending[0]
+		pending = pending[1:]
+		return t, true
+	}, closer(left, right))
+}
+
+// probe を読みながら、読み切った build の値とキーが一致するものをペアにしたイテレータを返す。
+// keepProbe が true の場合、一致しない probe の値も返す。
+// keepBuild が true の場合、一致しなかった build の値を最後に返す。
+func hashJoin[P any, B any, K comparable](probe Iter[P], build Iter[B], pkey func(P) (K, error), bkey func(B) (K, error), keepProbe bool, keepBuild bool) Iter[tuple.T2[*P, *B]] {
+	var builds []B
+	var table map[K][]int
+	var matched []bool
+	probeDone := false
+	cursor := 0
+	var pending []tuple.T2[*P, *B]
+
+	load := func() error {
+		defer Close(build)
+		table = map[K][]int{}
+		for {
+			b, ok := build.Next()
+			if !ok {
+				break
+			}
+			k, err := bkey(b)
+			if err != nil {
+				return err
+			}
+			table[k] = append(table[k], len(builds))
+			builds = append(builds, b)
+		}
+		matched = make([]bool, len(builds))
+		return build.Err()
+	}
+
+	return FromFuncWithClose(func(ctx Context) (tuple.T2[*P, *B], bool) {
+		if table == nil {
+			if err := load(); err != nil {
+				ctx.SetErr(err)
+				return tuple.T2[*P, *B]{}, false
+			}
+		}
+		for len(pending) == 0 {
+			if probeDone {
+				if !keepBuild {
+					return tuple.T2[*P, *B]{}, false
+				}
+				for cursor < len(builds) && matched[cursor] {
+					cursor++
+				}
+				if cursor >= len(builds) {
+					return tuple.T2[*P, *B]{}, false
+				}
+				b := builds[cursor]
+				cursor++
+				return tuple.NewT2[*P, *B](nil, &b), true
+			}
+
+			p, ok := probe.Next()
+			if !ok {
+				if err := probe.Err(); err != nil {
+					ctx.SetErr(err)
+					return tuple.T2[*P, *B]{}, false
+				}
+				probeDone = true
+				continue
+			}
+			k, err := pkey(p)
+			if err != nil {
+				ctx.SetErr(err)
+				return tuple.T2[*P, *B]{}, false
+			}
+			indices := table[k]
+			if len(indices) == 0 {
+				if keepProbe {
+					pending = append(pending, tuple.NewT2[*P, *B](&p, nil))
+				}
+				continue
+			}
+			for _, i := range indices {
+				b := builds[i]
+				matched[i] = true
+				pending = append(pending, tuple.NewT2(&p, &b))
+			}
+		}
+		t := pending[0]
+		pending = pending[1:]
+		return t, true
+	}, closer(probe, build))
+}
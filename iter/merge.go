@@ -0,0 +1,155 @@
+package iter
+
+import (
+	"container/heap"
+
+	"github.com/thamaji/gu/tuple"
+	"golang.org/x/exp/constraints"
+)
+
+// ソート済みのイテレータを併合し、ソートされたひとつのイテレータを返す。
+// 等しい値は前の引数のイテレータの値から順に返される。
+// いずれかのイテレータでエラーが起きた場合は停止し、Err はそのエラーを返す。
+func MergeSorted[V any](less func(V, V) (bool, error), iters ...Iter[V]) Iter[V] {
+	return mergeSorted(less, iters)
+}
+
+// 関数の返すキーでソート済みのイテレータを併合し、ソートされたひとつのイテレータを返す。
+// 等しい値は前の引数のイテレータの値から順に返される。
+// いずれかのイテレータでエラーが起きた場合は停止し、Err はそのエラーを返す。
+func MergeSortedBy[V any, K constraints.Ordered](f func(V) (K, error), iters ...Iter[V]) Iter[V] {
+	return Map(mergeSortedByKey(f, iters), func(t tuple.T2[K, V]) (V, error) {
+		return t.V2, nil
+	})
+}
+
+// 関数の返すキーでソート済みのイテレータを併合し、キーの重複を除いたイテレータを返す。
+// キーが等しい値は、最初に現れたものだけが返される。
+// いずれかのイテレータでエラーが起きた場合は停止し、Err はそのエラーを返す。
+func MergeSortedDistinctBy[V any, K constraints.Ordered](f func(V) (K, error), iters ...Iter[V]) Iter[V] {
+	var prev K
+	started := false
+	return Collect(mergeSortedByKey(f, iters), func(t tuple.T2[K, V]) (V, bool, error) {
+		if started && t.V1 == prev {
+			return *new(V), false, nil
+		}
+		prev, started = t.V1, true
+		return t.V2, true, nil
+	})
+}
+
+func mergeSortedByKey[V any, K constraints.Ordered](f func(V) (K, error), iters []Iter[V]) Iter[tuple.T2[K, V]] {
+	keyed := make([]Iter[tuple.T2[K, V]], len(iters))
+	for i := range iters {
+		keyed[i] = Map(iters[i], func(v V) (tuple.T2[K, V], error) {
+			k, err := f(v)
+			return tuple.NewT2(k, v), err
+		})
+	}
+	return mergeSorted(func(t1 tuple.T2[K, V], t2 tuple.T2[K, V]) (bool, error) {
+		return t1.V1 < t2.V1, nil
+	}, keyed)
+}
+
+type mergeItem[V any] struct {
+	v     V
+	index int
+}
+
+type mergeHeap[V any] struct {
+	items []mergeItem[V]
+	less  func(V, V) (bool, error)
+	err   error
+}
+
+func (h *mergeHeap[V]) Len() int {
+	return len(h.items)
+}
+
+func (h *mergeHeap[V]) Less(i, j int) bool {
+	if h.err != nil {
+		return false
+	}
+	ok, err := h.less(h.items[i].v, h.items[j].v)
+	if err != nil {
+		h.err = err
+		return false
+	}
+	if ok {
+		return true
+	}
+	// 等しい値は前のイテレータの値を先にする。
+	ok, err = h.less(h.items[j].v, h.items[i].v)
+	if err != nil {
+		h.err = err
+		return false
+	}
+	return !ok && h.items[i].index < h.items[j].index
+}
+
+func (h *mergeHeap[V]) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+}
+
+func (h *mergeHeap[V]) Push(x any) {
+	h.items = append(h.items, x.(mergeItem[V]))
+}
+
+func (h *mergeHeap[V]) Pop() any {
+	item := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return item
+}
+
+// ソート済みのイテレータをヒープで併合したイテレータを返す。
+func mergeSorted[V any](less func(V, V) (bool, error), iters []Iter[V]) Iter[V] {
+	h := &mergeHeap[V]{less: less}
+	started := false
+	return FromFuncWithClose(func(ctx Context) (V, bool) {
+		if !started {
+			started = true
+			for i, iter := range iters {
+				v, ok := iter.Next()
+				if !ok {
+					if err := iter.Err(); err != nil {
+						ctx.SetErr(err)
+						return *new(V), false
+					}
+					continue
+				}
+				h.items = append(h.items, mergeItem[V]{v: v, index: i})
+			}
+			heap.Init(h)
+		}
+		if h.err != nil {
+			ctx.SetErr(h.err)
+			return *new(V), false
+		}
+		if h.Len() == 0 {
+			return *new(V), false
+		}
+
+		top := h.items[0]
+		if v, ok := iters[top.index].Next(); ok {
+			h.items[0].v = v
+			heap.Fix(h, 0)
+		} else {
+			if err := iters[top.index].Err(); err != nil {
+				ctx.SetErr(err)
+				return *new(V), false
+			}
+			heap.Pop(h)
+		}
+		if h.err != nil {
+			ctx.SetErr(h.err)
+			return *new(V), false
+		}
+		return top.v, true
+	}, func() error {
+		closers := make([]any, len(iters))
+		for i := range iters {
+			closers[i] = iters[i]
+		}
+		return closer(closers...)()
+	})
+}
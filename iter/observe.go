@@ -0,0 +1,123 @@
+package iter
+
+import (
+	"expvar"
+	"time"
+)
+
+// イテレータの観測結果を受け取るもの。name は Observe や ObserveFunc に渡した名前。
+// 複数のゴルーチンから呼ばれることがある。
+type Observer interface {
+	// 値を読んだときに、Next にかかった時間とともに呼ばれる。
+	OnNext(name string, d time.Duration)
+	// 関数を実行したときに、かかった時間と関数の返したエラーとともに呼ばれる。
+	OnCallback(name string, d time.Duration, err error)
+	// 終了したときや閉じられたときに一度だけ、読んだ値の数と停止したエラーとともに呼ばれる。
+	OnDone(name string, count int, err error)
+}
+
+// 関数で実装した Observer。nil の関数は呼ばれない。
+type ObserverFuncs struct {
+	Next     func(name string, d time.Duration)
+	Callback func(name string, d time.Duration, err error)
+	Done     func(name string, count int, err error)
+}
+
+func (o ObserverFuncs) OnNext(name string, d time.Duration) {
+	if o.Next != nil {
+		o.Next(name, d)
+	}
+}
+
+func (o ObserverFuncs) OnCallback(name string, d time.Duration, err error) {
+	if o.Callback != nil {
+		o.Callback(name, d, err)
+	}
+}
+
+func (o ObserverFuncs) OnDone(name string, count int, err error) {
+	if o.Done != nil {
+		o.Done(name, count, err)
+	}
+}
+
+// 値を読むたびに observer に通知するイテレータを返す。
+// Next にかかる時間には、元のイテレータより前の段の時間も含まれる。
+func Observe[V any](iter Iter[V], name string, observer Observer) Iter[V] {
+	count := 0
+	done := false
+	finish := func(err error) {
+		if !done {
+			done = true
+			observer.OnDone(name, count, err)
+		}
+	}
+	return FromFuncWithClose(func(ctx Context) (V, bool) {
+		start := time.Now()
+		v, ok := iter.Next()
+		if !ok {
+			err := iter.Err()
+			ctx.SetErr(err)
+			finish(err)
+			return *new(V), false
+		}
+		observer.OnNext(name, time.Since(start))
+		count++
+		return v, true
+	}, func() error {
+		err := Close(iter)
+		finish(err)
+		return err
+	})
+}
+
+// 関数を実行するたびに observer に通知する関数を返す。
+// Map や FilterBy などに渡す関数を包んで、関数にかかる時間を測るのに使う。
+func ObserveFunc[V1 any, V2 any](name string, observer Observer, f func(V1) (V2, error)) func(V1) (V2, error) {
+	return func(v1 V1) (V2, error) {
+		start := time.Now()
+		v2, err := f(v1)
+		observer.OnCallback(name, time.Since(start), err)
+		return v2, err
+	}
+}
+
+// expvar.Map に観測結果を記録する Observer を返す。
+// 名前ごとに次のキーを記録する。時間の単位はナノ秒。
+//
+//	<name>.count          読んだ値の数
+//	<name>.next_ns        Next にかかった時間の合計
+//	<name>.callbacks      関数を実行した回数
+//	<name>.callback_ns    関数にかかった時間の合計
+//	<name>.callback_errs  関数がエラーを返した回数
+//	<name>.done           終了した回数
+//	<name>.err            最後に停止したエラー
+func NewExpvarObserver(m *expvar.Map) Observer {
+	return expvarObserver{m: m}
+}
+
+type expvarObserver struct {
+	m *expvar.Map
+}
+
+func (o expvarObserver) OnNext(name string, d time.Duration) {
+	o.m.Add(name+".count", 1)
+	o.m.Add(name+".next_ns", int64(d))
+}
+
+func (o expvarObserver) OnCallback(name string, d time.Duration, err error) {
+	o.m.Add(name+".callbacks", 1)
+	o.m.Add(name+".callback_ns", int64(d))
+	if err != nil {
+		o.m.Add(name+".callback_errs", 1)
+	}
+}
+
+func (o expvarObserver) OnDone(name string, count int, err error) {
+	o.m.Add(name+".done", 1)
+	if err != nil {
+		s := new(expvar.String)
+		s.Set(err.Error())
+		o.m.Set(name+".err", s)
+	}
+}
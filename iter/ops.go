@@ -1,18 +1,19 @@
 package iter
 
 import (
-	"errors"
-
+	"github.com/thamaji/gu/errs"
 	"github.com/thamaji/gu/must"
 	"github.com/thamaji/gu/tuple"
 	"golang.org/x/exp/constraints"
 )
 
 // 値の数を返す。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func Len[V any](iter Iter[V]) (int, error) {
+	defer Close(iter)
 	c := 0
 	for {
-		if _, ok := iter.Next(); ok {
+		if _, ok := iter.Next(); !ok {
 			break
 		}
 		c++
@@ -29,7 +30,9 @@ func MustLen[V any](iter Iter[V]) int {
 }
 
 // 指定した位置の値を返す。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func Get[V any](iter Iter[V], index int) (V, bool, error) {
+	defer Close(iter)
 	i := 0
 	for {
 		v, ok := iter.Next()
@@ -49,6 +52,7 @@ func MustGet[V any](iter Iter[V], index int) (V, bool) {
 }
 
 // 指定した位置の値を返す。無い場合はvを返す。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func GetOrElse[V any](iter Iter[V], index int, v V) (V, error) {
 	v, ok, err := Get(iter, index)
 	if !ok {
@@ -63,6 +67,7 @@ func MustGetOrElse[V any](iter Iter[V], index int, v V) V {
 }
 
 // 指定した位置の値のポインタを返す。無い場合はnilを返す。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func GetOrNil[V any](iter Iter[V], index int) (*V, error) {
 	v, ok, err := Get(iter, index)
 	if !ok {
@@ -77,6 +82,7 @@ func MustGetOrNil[V any](iter Iter[V], index int) *V {
 }
 
 // 指定した位置の値を返す。無い場合はゼロ値を返す。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func GetOrZero[V any](iter Iter[V], index int) (V, error) {
 	v, ok, err := Get(iter, index)
 	if !ok {
@@ -91,6 +97,7 @@ func MustGetOrZero[V any](iter Iter[V], index int) V {
 }
 
 // 指定した位置の要素を返す。無い場合は関数の実行結果を返す。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func GetOrFunc[V any](iter Iter[V], index int, f func() (V, error)) (V, error) {
 	v, ok, err := Get(iter, index)
 	if err != nil {
@@ -108,6 +115,7 @@ func MustGetOrFunc[V any](iter Iter[V], index int, f func() (V, error)) V {
 }
 
 // 先頭の値を返す。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func GetFirst[V any](iter Iter[V]) (V, bool, error) {
 	return Get(iter, 0)
 }
@@ -118,6 +126,7 @@ func MustGetFirst[V any](iter Iter[V]) (V, bool) {
 }
 
 // 先頭の値を返す。無い場合はvを返す。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func GetFirstOrElse[V any](iter Iter[V], v V) (V, error) {
 	v, ok, err := GetFirst(iter)
 	if !ok {
@@ -132,7 +141,9 @@ func MustGetFirstOrElse[V any](iter Iter[V], v V) V {
 }
 
 // 終端の値を返す。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func GetLast[V any](iter Iter[V]) (V, bool, error) {
+	defer Close(iter)
 	v := *new(V)
 	ok := false
 	for {
@@ -152,6 +163,7 @@ func MustGetLast[V any](iter Iter[V]) (V, bool) {
 }
 
 // 終端の値を返す。無い場合はvを返す。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func GetLastOrElse[V any](iter Iter[V], v V) (V, error) {
 	v, ok, err := GetLast(iter)
 	if !ok {
@@ -170,16 +182,31 @@ func Concat[V any](iter1 Iter[V], iter2 ...Iter[V]) Iter[V] {
 	cursor := 0
 	iters := append([]Iter[V]{iter1}, iter2...)
 
-	return FromFunc(func(ctx Context) (V, bool) {
-		if cursor >= len(iters) {
-			return *new(V), false
-		}
-		v, ok := iters[cursor].Next()
-		if !ok {
-			ctx.SetErr(iters[cursor].Err())
+	return FromFuncWithClose(func(ctx Context) (V, bool) {
+		for cursor < len(iters) {
+			if v, ok := iters[cursor].Next(); ok {
+				return v, true
+			}
+			if err := iters[cursor].Err(); err != nil {
+				ctx.SetErr(err)
+				return *new(V), false
+			}
+			err := Close(iters[cursor])
 			cursor++
+			if err != nil {
+				ctx.SetErr(err)
+				return *new(V), false
+			}
 		}
-		return v, ok
+		return *new(V), false
+	}, func() error {
+		var err error
+		for ; cursor < len(iters); cursor++ {
+			if err1 := Close(iters[cursor]); err == nil {
+				err = err1
+			}
+		}
+		return err
 	})
 }
 
@@ -191,7 +218,7 @@ func Append[V any](iter Iter[V], v ...V) Iter[V] {
 // 指定した位置に値を追加する。
 func Insert[V any](iter Iter[V], index int, v ...V) Iter[V] {
 	i := 0
-	return FromFunc(func(ctx Context) (V, bool) {
+	return FromFuncWithClose(func(ctx Context) (V, bool) {
 		if i >= index && i < index+len(v) {
 			v1 := v[i-index]
 			i++
@@ -203,13 +230,13 @@ func Insert[V any](iter Iter[V], index int, v ...V) Iter[V] {
 		}
 		i++
 		return v, ok
-	})
+	}, closer(iter))
 }
 
 // 指定した位置の値を削除する。
 func Remove[V any](iter Iter[V], index int) Iter[V] {
 	i := 0
-	return FromFunc(func(ctx Context) (V, bool) {
+	return FromFuncWithClose(func(ctx Context) (V, bool) {
 		for {
 			v, ok := iter.Next()
 			if !ok {
@@ -223,11 +250,13 @@ func Remove[V any](iter Iter[V], index int) Iter[V] {
 			}
 			return v, ok
 		}
-	})
+	}, closer(iter))
 }
 
 // 値ごとに関数を実行する。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func ForEach[V any](iter Iter[V], f func(V) error) error {
+	defer Close(iter)
 	for {
 		v, ok := iter.Next()
 		if !ok {
@@ -248,7 +277,10 @@ func MustForEach[V any](iter Iter[V], f func(V) error) {
 }
 
 // 他のイテレータと関数で比較し、一致していたらtrueを返す。
+// iter1 と iter2 は閉じられるので、読み残した値はあとから読めない。
 func EqualBy[V any](iter1 Iter[V], iter2 Iter[V], f func(V, V) (bool, error)) (bool, error) {
+	defer Close(iter1)
+	defer Close(iter2)
 	for {
 		v1, ok1 := iter1.Next()
 		if !ok1 {
@@ -285,6 +317,7 @@ func MustEqualBy[V any](iter1 Iter[V], iter2 Iter[V], f func(V, V) (bool, error)
 }
 
 // 他のイテレータと一致していたらtrueを返す。
+// iter1 と iter2 は閉じられるので、読み残した値はあとから読めない。
 func Equal[V comparable](iter1 Iter[V], iter2 Iter[V]) (bool, error) {
 	return EqualBy(iter1, iter2, func(v1 V, v2 V) (bool, error) { return v1 == v2, nil })
 }
@@ -295,6 +328,7 @@ func MustEqual[V comparable](iter1 Iter[V], iter2 Iter[V]) bool {
 }
 
 // 条件を満たす値の数を返す。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func CountBy[V any](iter Iter[V], f func(V) (bool, error)) (int, error) {
 	return Len(FilterBy(iter, f))
 }
@@ -305,6 +339,7 @@ func MustCountBy[V any](iter Iter[V], f func(V) (bool, error)) int {
 }
 
 // 一致する値の数を返す。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func Count[V comparable](iter Iter[V], v V) (int, error) {
 	return Len(Filter(iter, v))
 }
@@ -317,7 +352,7 @@ func MustCount[V comparable](iter Iter[V], v V) int {
 // 位置のイテレータを返す。
 func Indices[V any](iter Iter[V]) Iter[int] {
 	i := 0
-	return FromFunc(func(ctx Context) (int, bool) {
+	return FromFuncWithClose(func(ctx Context) (int, bool) {
 		if _, ok := iter.Next(); !ok {
 			ctx.SetErr(iter.Err())
 			return 0, false
@@ -325,12 +360,12 @@ func Indices[V any](iter Iter[V]) Iter[int] {
 		v := i
 		i++
 		return v, true
-	})
+	}, closer(iter))
 }
 
 // 値を変換したイテレータを返す。
 func Map[V1 any, V2 any](iter Iter[V1], f func(V1) (V2, error)) Iter[V2] {
-	return FromFunc(func(ctx Context) (V2, bool) {
+	return FromFuncWithClose(func(ctx Context) (V2, bool) {
 		v1, ok := iter.Next()
 		if !ok {
 			ctx.SetErr(iter.Err())
@@ -342,11 +377,13 @@ func Map[V1 any, V2 any](iter Iter[V1], f func(V1) (V2, error)) Iter[V2] {
 			return *new(V2), false
 		}
 		return v2, true
-	})
+	}, closer(iter))
 }
 
 // 値を順に演算する。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func Reduce[V any](iter Iter[V], f func(V, V) (V, error)) (V, error) {
+	defer Close(iter)
 	var err error
 	v, ok := iter.Next()
 	if ok {
@@ -373,6 +410,7 @@ func MustReduce[V any](iter Iter[V], f func(V, V) (V, error)) V {
 }
 
 // 値の合計を演算する。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func Sum[V constraints.Ordered | constraints.Complex](iter Iter[V]) (V, error) {
 	return Reduce(iter, func(sum V, v V) (V, error) {
 		return sum + v, nil
@@ -385,6 +423,7 @@ func MustSum[V constraints.Ordered | constraints.Complex](iter Iter[V]) V {
 }
 
 // 値を変換して合計を演算する。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func SumBy[V1 any, V2 constraints.Ordered | constraints.Complex](iter Iter[V1], f func(V1) (V2, error)) (V2, error) {
 	return Sum(Map(iter, f))
 }
@@ -395,6 +434,7 @@ func MustSumBy[V1 any, V2 constraints.Ordered | constraints.Complex](iter Iter[V
 }
 
 // 最大の値を返す。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func Max[V constraints.Ordered](iter Iter[V]) (V, error) {
 	return Reduce(iter, func(max V, v V) (V, error) {
 		if max < v {
@@ -410,6 +450,7 @@ func MustMax[V constraints.Ordered](iter Iter[V]) V {
 }
 
 // 値を変換して最大の値を返す。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func MaxBy[V1 any, V2 constraints.Ordered](iter Iter[V1], f func(V1) (V2, error)) (V2, error) {
 	return Max(Map(iter, f))
 }
@@ -420,6 +461,7 @@ func MustMaxBy[V1 any, V2 constraints.Ordered](iter Iter[V1], f func(V1) (V2, er
 }
 
 // 最小の値を返す。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func Min[V constraints.Ordered](iter Iter[V]) (V, error) {
 	return Reduce(iter, func(min V, v V) (V, error) {
 		if min > v {
@@ -435,6 +477,7 @@ func MustMin[V constraints.Ordered](iter Iter[V]) V {
 }
 
 // 値を変換して最小の値を返す。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func MinBy[V1 any, V2 constraints.Ordered](iter Iter[V1], f func(V1) (V2, error)) (V2, error) {
 	return Min(Map(iter, f))
 }
@@ -445,7 +488,9 @@ func MustMinBy[V1 any, V2 constraints.Ordered](iter Iter[V1], f func(V1) (V2, er
 }
 
 // 初期値と値を順に演算する。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func Fold[V1 any, V2 any](iter Iter[V1], v V2, f func(V2, V1) (V2, error)) (V2, error) {
+	defer Close(iter)
 	var err error
 	for {
 		v1, ok := iter.Next()
@@ -468,8 +513,72 @@ func MustFold[V1 any, V2 any](iter Iter[V1], v V2, f func(V2, V1) (V2, error)) V
 	return must.Must1(Fold(iter, v, f))
 }
 
+// 初期値と値を順に演算し、途中の結果を並べたイテレータを返す。
+func Scan[V1 any, V2 any](iter Iter[V1], v V2, f func(V2, V1) (V2, error)) Iter[V2] {
+	return FromFuncWithClose(func(ctx Context) (V2, bool) {
+		v1, ok := iter.Next()
+		if !ok {
+			ctx.SetErr(iter.Err())
+			return *new(V2), false
+		}
+		v2, err := f(v, v1)
+		if err != nil {
+			ctx.SetErr(err)
+			return *new(V2), false
+		}
+		v = v2
+		return v2, true
+	}, closer(iter))
+}
+
+// 状態を持ちながら値を変換したイテレータを返す。
+// 関数は次の状態と、０個以上の変換した値を返す。
+// 値を読み切ったあと、flush が返す値が最後に加えられる。flush は nil でもよい。
+func MapWithState[V1 any, S any, V2 any](iter Iter[V1], state S, f func(S, V1) (S, []V2, error), flush func(S) ([]V2, error)) Iter[V2] {
+	// pending は関数の返したスライスなので書き換えず、位置だけを進める。
+	var pending []V2
+	i := 0
+	done := false
+	return FromFuncWithClose(func(ctx Context) (V2, bool) {
+		for i >= len(pending) {
+			if done {
+				return *new(V2), false
+			}
+			v1, ok := iter.Next()
+			if !ok {
+				if err := iter.Err(); err != nil {
+					ctx.SetErr(err)
+					return *new(V2), false
+				}
+				done = true
+				if flush == nil {
+					continue
+				}
+				values, err := flush(state)
+				if err != nil {
+					ctx.SetErr(err)
+					return *new(V2), false
+				}
+				pending, i = values, 0
+				continue
+			}
+			next, values, err := f(state, v1)
+			if err != nil {
+				ctx.SetErr(err)
+				return *new(V2), false
+			}
+			state, pending, i = next, values, 0
+		}
+		v2 := pending[i]
+		i++
+		return v2, true
+	}, closer(iter))
+}
+
 // 条件を満たす最初の値の位置を返す。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func IndexBy[V any](iter Iter[V], f func(V) (bool, error)) (int, error) {
+	defer Close(iter)
 	i := 0
 	for {
 		v, ok := iter.Next()
@@ -493,6 +602,7 @@ func MustIndexBy[V any](iter Iter[V], f func(V) (bool, error)) int {
 }
 
 // 一致する最初の値の位置を返す。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func Index[V comparable](iter Iter[V], v V) (int, error) {
 	return IndexBy(iter, func(v1 V) (bool, error) { return v1 == v, nil })
 }
@@ -503,7 +613,9 @@ func MustIndex[V comparable](iter Iter[V], v V) int {
 }
 
 // 条件を満たす最後の値の位置を返す。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func LastIndexBy[V any](iter Iter[V], f func(V) (bool, error)) (int, error) {
+	defer Close(iter)
 	var err error
 	i := 0
 	index := -1
@@ -533,6 +645,7 @@ func MustLastIndexBy[V any](iter Iter[V], f func(V) (bool, error)) int {
 }
 
 // 一致する最後の値の位置を返す。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func LastIndex[V comparable](iter Iter[V], v V) (int, error) {
 	return LastIndexBy(iter, func(v1 V) (bool, error) { return v1 == v, nil })
 }
@@ -543,7 +656,9 @@ func MustLastIndex[V comparable](iter Iter[V], v V) int {
 }
 
 // 条件を満たす値を返す。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func FindBy[V any](iter Iter[V], f func(V) (bool, error)) (V, bool, error) {
+	defer Close(iter)
 	for {
 		v, ok := iter.Next()
 		if !ok {
@@ -565,6 +680,7 @@ func MustFindBy[V any](iter Iter[V], f func(V) (bool, error)) (V, bool) {
 }
 
 // 一致する値を返す。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func Find[V comparable](iter Iter[V], v V) (V, bool, error) {
 	return FindBy(iter, func(v1 V) (bool, error) { return v1 == v, nil })
 }
@@ -575,6 +691,7 @@ func MustFind[V comparable](iter Iter[V], v V) (V, bool) {
 }
 
 // 条件を満たす値が存在したらtrueを返す。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func ExistsBy[V any](iter Iter[V], f func(V) (bool, error)) (bool, error) {
 	index, err := IndexBy(iter, f)
 	return index >= 0, err
@@ -586,6 +703,7 @@ func MustExistsBy[V any](iter Iter[V], f func(V) (bool, error)) bool {
 }
 
 // 一致する値が存在したらtrueを返す。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func Exists[V comparable](iter Iter[V], v V) (bool, error) {
 	return ExistsBy(iter, func(v1 V) (bool, error) { return v1 == v, nil })
 }
@@ -596,6 +714,7 @@ func MustExists[V comparable](iter Iter[V], v V) bool {
 }
 
 // すべての値が条件を満たせばtrueを返す。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func ForAllBy[V any](iter Iter[V], f func(V) (bool, error)) (bool, error) {
 	ok, err := ExistsBy(iter, func(v V) (bool, error) {
 		ok, err := f(v)
@@ -613,6 +732,7 @@ func MustForAllBy[V any](iter Iter[V], f func(V) (bool, error)) bool {
 }
 
 // すべての値が一致したらtrueを返す。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func ForAll[V comparable](iter Iter[V], v V) (bool, error) {
 	return ForAllBy(iter, func(v1 V) (bool, error) { return v1 == v, nil })
 }
@@ -623,7 +743,9 @@ func MustForAll[V comparable](iter Iter[V], v V) bool {
 }
 
 // 他のイテレータの値がひとつでも存在していたらtrueを返す。
+// iter と subset は閉じられるので、読み残した値はあとから読めない。
 func ContainsAny[V comparable](iter Iter[V], subset Iter[V]) (bool, error) {
+	defer Close(iter)
 	slice, err := ToSlice(subset)
 	if err != nil {
 		return false, err
@@ -648,7 +770,9 @@ func MustContainsAny[V comparable](iter Iter[V], subset Iter[V]) bool {
 }
 
 // 他のイテレータの値がすべて存在していたらtrueを返す。
+// iter と subset は閉じられるので、読み残した値はあとから読めない。
 func ContainsAll[V comparable](iter Iter[V], subset Iter[V]) (bool, error) {
+	defer Close(iter)
 	slice, err := ToSlice(subset)
 	if err != nil {
 		return false, err
@@ -676,7 +800,10 @@ func MustContainsAll[V comparable](iter Iter[V], subset Iter[V]) bool {
 }
 
 // 先頭が他のイテレータと一致していたらtrueを返す。
+// iter と subset は閉じられるので、読み残した値はあとから読めない。
 func StartsWith[V comparable](iter Iter[V], subset Iter[V]) (bool, error) {
+	defer Close(iter)
+	defer Close(subset)
 	for {
 		v1, ok1 := iter.Next()
 		v2, ok2 := subset.Next()
@@ -701,7 +828,9 @@ func MustStartsWith[V comparable](iter Iter[V], subset Iter[V]) bool {
 }
 
 // 終端が他のイテレータと一致していたらtrueを返す。
+// iter と subset は閉じられるので、読み残した値はあとから読めない。
 func EndsWith[V comparable](iter Iter[V], subset Iter[V]) (bool, error) {
+	defer Close(iter)
 	slice, err := ToSlice(subset)
 	if err != nil {
 		return false, err
@@ -752,7 +881,7 @@ func ReplaceAll[V comparable](iter Iter[V], old V, new V) Iter[V] {
 
 // 条件を満たす値だけのイテレータを返す。
 func FilterBy[V any](iter Iter[V], f func(V) (bool, error)) Iter[V] {
-	return FromFunc(func(ctx Context) (V, bool) {
+	return FromFuncWithClose(func(ctx Context) (V, bool) {
 		for {
 			v, ok := iter.Next()
 			if !ok {
@@ -769,7 +898,7 @@ func FilterBy[V any](iter Iter[V], f func(V) (bool, error)) Iter[V] {
 			}
 			return v, true
 		}
-	})
+	}, closer(iter))
 }
 
 // 一致する値だけのイテレータを返す。
@@ -804,10 +933,13 @@ func SplitBy[V any](iter Iter[V], f func(V) (bool, error)) (Iter[V], Iter[V], er
 
 		ok, err := f(v)
 		if err != nil {
+			_ = Close(iter)
 			return nil, nil, err
 		}
 		if ok {
-			return FromSlice(remain), Insert(iter, 0, v), nil
+			rest := NewPeekable(iter)
+			rest.Unread(v)
+			return FromSlice(remain), rest, nil
 		}
 		remain = append(remain, v)
 	}
@@ -842,6 +974,7 @@ func SplitAfterBy[V any](iter Iter[V], f func(V) (bool, error)) (Iter[V], Iter[V
 
 		ok, err := f(v)
 		if err != nil {
+			_ = Close(iter)
 			return nil, nil, err
 		}
 		if ok {
@@ -867,7 +1000,10 @@ func MustSplitAfter[V comparable](iter Iter[V], v V) (Iter[V], Iter[V]) {
 }
 
 // 条件を満たすイテレータと満たさないイテレータを返す。
+// 元のイテレータは読み切られる。読み切らずに分割する場合は LazyPartitionBy を使う。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func PartitionBy[V any](iter Iter[V], f func(V) (bool, error)) (Iter[V], Iter[V], error) {
+	defer Close(iter)
 	slice1, slice2 := []V{}, []V{}
 	for {
 		v, ok := iter.Next()
@@ -897,6 +1033,7 @@ func MustPartitionBy[V any](iter Iter[V], f func(V) (bool, error)) (Iter[V], Ite
 }
 
 // 値の一致するイテレータと一致しないイテレータを返す。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func Partition[V comparable](iter Iter[V], v V) (Iter[V], Iter[V], error) {
 	return PartitionBy(iter, func(v1 V) (bool, error) { return v1 == v, nil })
 }
@@ -909,7 +1046,7 @@ func MustPartition[V comparable](iter Iter[V], v V) (Iter[V], Iter[V]) {
 // 条件を満たし続ける先頭の値のイテレータを返す。
 func TakeWhileBy[V any](iter Iter[V], f func(V) (bool, error)) Iter[V] {
 	done := false
-	return FromFunc(func(ctx Context) (V, bool) {
+	return FromFuncWithClose(func(ctx Context) (V, bool) {
 		if done {
 			return *new(V), false
 		}
@@ -930,7 +1067,7 @@ func TakeWhileBy[V any](iter Iter[V], f func(V) (bool, error)) Iter[V] {
 			return *new(V), false
 		}
 		return v, true
-	})
+	}, closer(iter))
 }
 
 // 一致し続ける先頭の値のイテレータを返す。
@@ -951,9 +1088,13 @@ func Take[V any](iter Iter[V], n int) Iter[V] {
 // 条件を満たし続ける先頭の値を除いたイテレータを返す。
 func DropWhileBy[V any](iter Iter[V], f func(V) (bool, error)) Iter[V] {
 	done := false
-	return FromFunc(func(ctx Context) (V, bool) {
+	return FromFuncWithClose(func(ctx Context) (V, bool) {
 		if done {
-			return iter.Next()
+			v, ok := iter.Next()
+			if !ok {
+				ctx.SetErr(iter.Err())
+			}
+			return v, ok
 		}
 
 		for {
@@ -974,7 +1115,7 @@ func DropWhileBy[V any](iter Iter[V], f func(V) (bool, error)) Iter[V] {
 			done = true
 			return v, true
 		}
-	})
+	}, closer(iter))
 }
 
 // 一致し続ける先頭の値を除いたイテレータを返す。
@@ -1006,10 +1147,13 @@ func SpanBy[V any](iter Iter[V], f func(V) (bool, error)) (Iter[V], Iter[V], err
 
 		ok, err := f(v)
 		if err != nil {
+			_ = Close(iter)
 			return nil, nil, err
 		}
 		if !ok {
-			return FromSlice(remain), Insert(iter, 0, v), nil
+			rest := NewPeekable(iter)
+			rest.Unread(v)
+			return FromSlice(remain), rest, nil
 		}
 		remain = append(remain, v)
 	}
@@ -1036,9 +1180,10 @@ func Clean[V comparable](iter Iter[V]) Iter[V] {
 }
 
 // 重複を除いたイテレータを返す。
+// 読んだ値はすべて記憶される。メモリを制限する場合は DistinctWindow や ApproxDistinctBy を使う。
 func Distinct[V comparable](iter Iter[V]) Iter[V] {
 	m := map[V]struct{}{}
-	return FromFunc(func(ctx Context) (V, bool) {
+	return FromFuncWithClose(func(ctx Context) (V, bool) {
 		for {
 			v, ok := iter.Next()
 			if !ok {
@@ -1053,12 +1198,38 @@ func Distinct[V comparable](iter Iter[V]) Iter[V] {
 
 			return v, true
 		}
-	})
+	}, closer(iter))
+}
+
+// 関数の返すキーが重複する値を除いたイテレータを返す。
+func DistinctBy[V any, K comparable](iter Iter[V], f func(V) (K, error)) Iter[V] {
+	m := map[K]struct{}{}
+	return FromFuncWithClose(func(ctx Context) (V, bool) {
+		for {
+			v, ok := iter.Next()
+			if !ok {
+				ctx.SetErr(iter.Err())
+				return *new(V), false
+			}
+
+			k, err := f(v)
+			if err != nil {
+				ctx.SetErr(err)
+				return *new(V), false
+			}
+			if _, ok := m[k]; ok {
+				continue
+			}
+			m[k] = struct{}{}
+
+			return v, true
+		}
+	}, closer(iter))
 }
 
 // 条件を満たす値を変換したイテレータを返す。
 func Collect[V1 any, V2 any](iter Iter[V1], f func(V1) (V2, bool, error)) Iter[V2] {
-	return FromFunc(func(ctx Context) (V2, bool) {
+	return FromFuncWithClose(func(ctx Context) (V2, bool) {
 		for {
 			v1, ok := iter.Next()
 			if !ok {
@@ -1076,13 +1247,13 @@ func Collect[V1 any, V2 any](iter Iter[V1], f func(V1) (V2, bool, error)) Iter[V
 			}
 			return v2, true
 		}
-	})
+	}, closer(iter))
 }
 
 // 値と位置をペアにしたイテレータを返す。
 func ZipWithIndex[V any](iter Iter[V]) Iter[tuple.T2[V, int]] {
 	i := 0
-	return FromFunc(func(ctx Context) (tuple.T2[V, int], bool) {
+	return FromFuncWithClose(func(ctx Context) (tuple.T2[V, int], bool) {
 		v, ok := iter.Next()
 		if !ok {
 			ctx.SetErr(iter.Err())
@@ -1091,74 +1262,46 @@ func ZipWithIndex[V any](iter Iter[V]) Iter[tuple.T2[V, int]] {
 		t := tuple.NewT2(v, i)
 		i++
 		return t, true
-	})
+	}, closer(iter))
 }
 
 // ２つのイテレータの同じ位置の値をペアにしたイテレータを返す。
 func Zip2[V1 any, V2 any](iter1 Iter[V1], iter2 Iter[V2]) Iter[tuple.T2[V1, V2]] {
-	return FromFunc(func(ctx Context) (tuple.T2[V1, V2], bool) {
+	return FromFuncWithClose(func(ctx Context) (tuple.T2[V1, V2], bool) {
 		v1, ok1 := iter1.Next()
 		v2, ok2 := iter2.Next()
 		if ok1 && ok2 {
 			return tuple.NewT2(v1, v2), true
 		}
-		errs := make([]error, 0, 2)
-		if err := iter1.Err(); err != nil {
-			errs = append(errs, err)
-		}
-		if err := iter2.Err(); err != nil {
-			errs = append(errs, err)
-		}
-		if len(errs) > 0 {
-			var b []byte
-			for i, err := range errs {
-				if i > 0 {
-					b = append(b, '\n')
-				}
-				b = append(b, err.Error()...)
-			}
-			ctx.SetErr(errors.New(string(b)))
-		}
+		es := errs.New()
+		es.Add(0, iter1.Err())
+		es.Add(1, iter2.Err())
+		ctx.SetErr(es.ErrOrNil())
 		return tuple.NewT2(*new(V1), *new(V2)), false
-	})
+	}, closer(iter1, iter2))
 }
 
 // ３つのイテレータの同じ位置の値をペアにしたイテレータを返す。
 func Zip3[V1 any, V2 any, V3 any](iter1 Iter[V1], iter2 Iter[V2], iter3 Iter[V3]) Iter[tuple.T3[V1, V2, V3]] {
-	return FromFunc(func(ctx Context) (tuple.T3[V1, V2, V3], bool) {
+	return FromFuncWithClose(func(ctx Context) (tuple.T3[V1, V2, V3], bool) {
 		v1, ok1 := iter1.Next()
 		v2, ok2 := iter2.Next()
 		v3, ok3 := iter3.Next()
 		if ok1 && ok2 && ok3 {
 			return tuple.NewT3(v1, v2, v3), true
 		}
-		errs := make([]error, 0, 3)
-		if err := iter1.Err(); err != nil {
-			errs = append(errs, err)
-		}
-		if err := iter2.Err(); err != nil {
-			errs = append(errs, err)
-		}
-		if err := iter3.Err(); err != nil {
-			errs = append(errs, err)
-		}
-		if len(errs) > 0 {
-			var b []byte
-			for i, err := range errs {
-				if i > 0 {
-					b = append(b, '\n')
-				}
-				b = append(b, err.Error()...)
-			}
-			ctx.SetErr(errors.New(string(b)))
-		}
+		es := errs.New()
+		es.Add(0, iter1.Err())
+		es.Add(1, iter2.Err())
+		es.Add(2, iter3.Err())
+		ctx.SetErr(es.ErrOrNil())
 		return tuple.NewT3(*new(V1), *new(V2), *new(V3)), false
-	})
+	}, closer(iter1, iter2, iter3))
 }
 
 // ４つのイテレータの同じ位置の値をペアにしたイテレータを返す。
 func Zip4[V1 any, V2 any, V3 any, V4 any](iter1 Iter[V1], iter2 Iter[V2], iter3 Iter[V3], iter4 Iter[V4]) Iter[tuple.T4[V1, V2, V3, V4]] {
-	return FromFunc(func(ctx Context) (tuple.T4[V1, V2, V3, V4], bool) {
+	return FromFuncWithClose(func(ctx Context) (tuple.T4[V1, V2, V3, V4], bool) {
 		v1, ok1 := iter1.Next()
 		v2, ok2 := iter2.Next()
 		v3, ok3 := iter3.Next()
@@ -1166,36 +1309,19 @@ func Zip4[V1 any, V2 any, V3 any, V4 any](iter1 Iter[V1], iter2 Iter[V2], iter3
 		if ok1 && ok2 && ok3 && ok4 {
 			return tuple.NewT4(v1, v2, v3, v4), true
 		}
-		errs := make([]error, 0, 4)
-		if err := iter1.Err(); err != nil {
-			errs = append(errs, err)
-		}
-		if err := iter2.Err(); err != nil {
-			errs = append(errs, err)
-		}
-		if err := iter3.Err(); err != nil {
-			errs = append(errs, err)
-		}
-		if err := iter4.Err(); err != nil {
-			errs = append(errs, err)
-		}
-		if len(errs) > 0 {
-			var b []byte
-			for i, err := range errs {
-				if i > 0 {
-					b = append(b, '\n')
-				}
-				b = append(b, err.Error()...)
-			}
-			ctx.SetErr(errors.New(string(b)))
-		}
+		es := errs.New()
+		es.Add(0, iter1.Err())
+		es.Add(1, iter2.Err())
+		es.Add(2, iter3.Err())
+		es.Add(3, iter4.Err())
+		ctx.SetErr(es.ErrOrNil())
 		return tuple.NewT4(*new(V1), *new(V2), *new(V3), *new(V4)), false
-	})
+	}, closer(iter1, iter2, iter3, iter4))
 }
 
 // ５つのイテレータの同じ位置の値をペアにしたイテレータを返す。
 func Zip5[V1 any, V2 any, V3 any, V4 any, V5 any](iter1 Iter[V1], iter2 Iter[V2], iter3 Iter[V3], iter4 Iter[V4], iter5 Iter[V5]) Iter[tuple.T5[V1, V2, V3, V4, V5]] {
-	return FromFunc(func(ctx Context) (tuple.T5[V1, V2, V3, V4, V5], bool) {
+	return FromFuncWithClose(func(ctx Context) (tuple.T5[V1, V2, V3, V4, V5], bool) {
 		v1, ok1 := iter1.Next()
 		v2, ok2 := iter2.Next()
 		v3, ok3 := iter3.Next()
@@ -1204,39 +1330,20 @@ func Zip5[V1 any, V2 any, V3 any, V4 any, V5 any](iter1 Iter[V1], iter2 Iter[V2]
 		if ok1 && ok2 && ok3 && ok4 && ok5 {
 			return tuple.NewT5(v1, v2, v3, v4, v5), true
 		}
-		errs := make([]error, 0, 5)
-		if err := iter1.Err(); err != nil {
-			errs = append(errs, err)
-		}
-		if err := iter2.Err(); err != nil {
-			errs = append(errs, err)
-		}
-		if err := iter3.Err(); err != nil {
-			errs = append(errs, err)
-		}
-		if err := iter4.Err(); err != nil {
-			errs = append(errs, err)
-		}
-		if err := iter5.Err(); err != nil {
-			errs = append(errs, err)
-		}
-		if len(errs) > 0 {
-			var b []byte
-			for i, err := range errs {
-				if i > 0 {
-					b = append(b, '\n')
-				}
-				b = append(b, err.Error()...)
-			}
-			ctx.SetErr(errors.New(string(b)))
-		}
+		es := errs.New()
+		es.Add(0, iter1.Err())
+		es.Add(1, iter2.Err())
+		es.Add(2, iter3.Err())
+		es.Add(3, iter4.Err())
+		es.Add(4, iter5.Err())
+		ctx.SetErr(es.ErrOrNil())
 		return tuple.NewT5(*new(V1), *new(V2), *new(V3), *new(V4), *new(V5)), false
-	})
+	}, closer(iter1, iter2, iter3, iter4, iter5))
 }
 
 // ６つのイテレータの同じ位置の値をペアにしたイテレータを返す。
 func Zip6[V1 any, V2 any, V3 any, V4 any, V5 any, V6 any](iter1 Iter[V1], iter2 Iter[V2], iter3 Iter[V3], iter4 Iter[V4], iter5 Iter[V5], iter6 Iter[V6]) Iter[tuple.T6[V1, V2, V3, V4, V5, V6]] {
-	return FromFunc(func(ctx Context) (tuple.T6[V1, V2, V3, V4, V5, V6], bool) {
+	return FromFuncWithClose(func(ctx Context) (tuple.T6[V1, V2, V3, V4, V5, V6], bool) {
 		v1, ok1 := iter1.Next()
 		v2, ok2 := iter2.Next()
 		v3, ok3 := iter3.Next()
@@ -1246,42 +1353,23 @@ func Zip6[V1 any, V2 any, V3 any, V4 any, V5 any, V6 any](iter1 Iter[V1], iter2
 		if ok1 && ok2 && ok3 && ok4 && ok5 && ok6 {
 			return tuple.NewT6(v1, v2, v3, v4, v5, v6), true
 		}
-		errs := make([]error, 0, 6)
-		if err := iter1.Err(); err != nil {
-			errs = append(errs, err)
-		}
-		if err := iter2.Err(); err != nil {
-			errs = append(errs, err)
-		}
-		if err := iter3.Err(); err != nil {
-			errs = append(errs, err)
-		}
-		if err := iter4.Err(); err != nil {
-			errs = append(errs, err)
-		}
-		if err := iter5.Err(); err != nil {
-			errs = append(errs, err)
-		}
-		if err := iter6.Err(); err != nil {
-			errs = append(errs, err)
-		}
-		if len(errs) > 0 {
-			var b []byte
-			for i, err := range errs {
-				if i > 0 {
-					b = append(b, '\n')
-				}
-				b = append(b, err.Error()...)
-			}
-			ctx.SetErr(errors.New(string(b)))
-		}
+		es := errs.New()
+		es.Add(0, iter1.Err())
+		es.Add(1, iter2.Err())
+		es.Add(2, iter3.Err())
+		es.Add(3, iter4.Err())
+		es.Add(4, iter5.Err())
+		es.Add(5, iter6.Err())
+		ctx.SetErr(es.ErrOrNil())
 		return tuple.NewT6(*new(V1), *new(V2), *new(V3), *new(V4), *new(V5), *new(V6)), false
-	})
+	}, closer(iter1, iter2, iter3, iter4, iter5, iter6))
 }
 
 // 値のペアを分離して２つのイテレータを返す。
+// 元のイテレータは読み切られる。読み切らずに分離する場合は LazyUnzip2 を使う。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func Unzip2[V1 any, V2 any](iter Iter[tuple.T2[V1, V2]]) (Iter[V1], Iter[V2], error) {
-	// TODO: イテレータを複製できれば、いちいち読み切る必要がなくなる
+	defer Close(iter)
 	slice1, slice2 := []V1{}, []V2{}
 	for {
 		t, ok := iter.Next()
@@ -1298,8 +1386,10 @@ func Unzip2[V1 any, V2 any](iter Iter[tuple.T2[V1, V2]]) (Iter[V1], Iter[V2], er
 }
 
 // 値のペアを分離して３つのイテレータを返す。
+// 元のイテレータは読み切られる。読み切らずに分離する場合は LazyUnzip3 を使う。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func Unzip3[V1 any, V2 any, V3 any](iter Iter[tuple.T3[V1, V2, V3]]) (Iter[V1], Iter[V2], Iter[V3], error) {
-	// TODO: イテレータを複製できれば、いちいち読み切る必要がなくなる
+	defer Close(iter)
 	slice1, slice2, slice3 := []V1{}, []V2{}, []V3{}
 	for {
 		t, ok := iter.Next()
@@ -1317,8 +1407,10 @@ func Unzip3[V1 any, V2 any, V3 any](iter Iter[tuple.T3[V1, V2, V3]]) (Iter[V1],
 }
 
 // 値のペアを分離して４つのイテレータを返す。
+// 元のイテレータは読み切られる。読み切らずに分離する場合は LazyUnzip4 を使う。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func Unzip4[V1 any, V2 any, V3 any, V4 any](iter Iter[tuple.T4[V1, V2, V3, V4]]) (Iter[V1], Iter[V2], Iter[V3], Iter[V4], error) {
-	// TODO: イテレータを複製できれば、いちいち読み切る必要がなくなる
+	defer Close(iter)
 	slice1, slice2, slice3, slice4 := []V1{}, []V2{}, []V3{}, []V4{}
 	for {
 		t, ok := iter.Next()
@@ -1337,8 +1429,10 @@ func Unzip4[V1 any, V2 any, V3 any, V4 any](iter Iter[tuple.T4[V1, V2, V3, V4]])
 }
 
 // 値のペアを分離して５つのイテレータを返す。
+// 元のイテレータは読み切られる。読み切らずに分離する場合は LazyUnzip5 を使う。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func Unzip5[V1 any, V2 any, V3 any, V4 any, V5 any](iter Iter[tuple.T5[V1, V2, V3, V4, V5]]) (Iter[V1], Iter[V2], Iter[V3], Iter[V4], Iter[V5], error) {
-	// TODO: イテレータを複製できれば、いちいち読み切る必要がなくなる
+	defer Close(iter)
 	slice1, slice2, slice3, slice4, slice5 := []V1{}, []V2{}, []V3{}, []V4{}, []V5{}
 	for {
 		t, ok := iter.Next()
@@ -1358,8 +1452,10 @@ func Unzip5[V1 any, V2 any, V3 any, V4 any, V5 any](iter Iter[tuple.T5[V1, V2, V
 }
 
 // 値のペアを分離して６つのイテレータを返す。
+// 元のイテレータは読み切られる。読み切らずに分離する場合は LazyUnzip6 を使う。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func Unzip6[V1 any, V2 any, V3 any, V4 any, V5 any, V6 any](iter Iter[tuple.T6[V1, V2, V3, V4, V5, V6]]) (Iter[V1], Iter[V2], Iter[V3], Iter[V4], Iter[V5], Iter[V6], error) {
-	// TODO: イテレータを複製できれば、いちいち読み切る必要がなくなる
+	defer Close(iter)
 	slice1, slice2, slice3, slice4, slice5, slice6 := []V1{}, []V2{}, []V3{}, []V4{}, []V5{}, []V6{}
 	for {
 		t, ok := iter.Next()
@@ -1380,7 +1476,9 @@ func Unzip6[V1 any, V2 any, V3 any, V4 any, V5 any, V6 any](iter Iter[tuple.T6[V
 }
 
 // 値ごとに関数の返すキーでグルーピングしたマップを返す。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func GroupBy[K comparable, V any](iter Iter[V], f func(V) (K, error)) (map[K][]V, error) {
+	defer Close(iter)
 	m := map[K][]V{}
 	for {
 		v, ok := iter.Next()
@@ -1412,7 +1510,7 @@ func MustGroupBy[K comparable, V any](iter Iter[V], f func(V) (K, error)) map[K]
 // 平坦化したイテレータを返す。
 func Flatten[V any](iter Iter[Iter[V]]) Iter[V] {
 	sub, ok := iter.Next()
-	return FromFunc(func(ctx Context) (V, bool) {
+	return FromFuncWithClose(func(ctx Context) (V, bool) {
 		for {
 			if !ok {
 				ctx.SetErr(iter.Err())
@@ -1426,9 +1524,19 @@ func Flatten[V any](iter Iter[Iter[V]]) Iter[V] {
 				ctx.SetErr(err)
 				return *new(V), false
 			}
+			if err := Close(sub); err != nil {
+				ok = false
+				ctx.SetErr(err)
+				return *new(V), false
+			}
 
 			sub, ok = iter.Next()
 		}
+	}, func() error {
+		if ok {
+			return closer(sub, iter)()
+		}
+		return Close(iter)
 	})
 }
 
@@ -1441,3 +1549,69 @@ func FlatMap[V1 any, V2 any](iter Iter[V1], f func(V1) (Iter[V2], error)) Iter[V
 func Join[V any](iter Iter[V], separator V) Iter[V] {
 	return Drop(FlatMap(iter, func(v V) (Iter[V], error) { return From(separator, v), nil }), 1)
 }
+
+// n個ごとのスライスのイテレータを返す。
+func Grouped[V any](iter Iter[V], n int) Iter[[]V] {
+	return FromFuncWithClose(func(ctx Context) ([]V, bool) {
+		if n <= 0 {
+			return nil, false
+		}
+		group := make([]V, 0, n)
+		for len(group) < n {
+			v, ok := iter.Next()
+			if !ok {
+				if err := iter.Err(); err != nil {
+					ctx.SetErr(err)
+					return nil, false
+				}
+				break
+			}
+			group = append(group, v)
+		}
+		return group, len(group) > 0
+	}, closer(iter))
+}
+
+// stepずつズラしたn個ごとのスライスのイテレータを返す。
+func Sliding[V any](iter Iter[V], n int, step int) Iter[[]V] {
+	window := make([]V, 0, n)
+	started := false
+	return FromFuncWithClose(func(ctx Context) ([]V, bool) {
+		if n <= 0 || step <= 0 {
+			return nil, false
+		}
+		if started {
+			if step < len(window) {
+				window = append(window[:0], window[step:]...)
+			} else {
+				for i := len(window); i < step; i++ {
+					if _, ok := iter.Next(); !ok {
+						ctx.SetErr(iter.Err())
+						return nil, false
+					}
+				}
+				window = window[:0]
+			}
+		}
+		started = true
+
+		read := false
+		for len(window) < n {
+			v, ok := iter.Next()
+			if !ok {
+				if err := iter.Err(); err != nil {
+					ctx.SetErr(err)
+					return nil, false
+				}
+				break
+			}
+			window = append(window, v)
+			read = true
+		}
+		// 新しい値を含まない末尾の窓は返さない。
+		if !read {
+			return nil, false
+		}
+		return append(make([]V, 0, len(window)), window...), true
+	}, closer(iter))
+}
@@ -0,0 +1,205 @@
+package iter
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/thamaji/gu/tuple"
+)
+
+func TestMapWithStateKeepsReturnedSlices(t *testing.T) {
+	returned := [][]int{}
+	iter := MapWithState(Range(1, 4, 1), 0, func(sum int, v int) (int, []int, error) {
+		values := []int{v * 10, v*10 + 1}
+		returned = append(returned, values)
+		return sum + v, values, nil
+	}, func(sum int) ([]int, error) {
+		values := []int{sum}
+		returned = append(returned, values)
+		return values, nil
+	})
+
+	got, err := ToSlice(iter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{10, 11, 20, 21, 30, 31, 6}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+
+	// 関数の返したスライスは書き換えられていないこと。
+	for i, values := range returned[:3] {
+		if values[0] != (i+1)*10 || values[1] != (i+1)*10+1 {
+			t.Fatalf("returned[%d] = %v, was modified", i, values)
+		}
+	}
+	if returned[3][0] != 6 {
+		t.Fatalf("flushed = %v, was modified", returned[3])
+	}
+}
+
+// 閉じられたかどうかを記録するイテレータ。
+func trackClose(values ...int) (Iter[int], *bool) {
+	closed := false
+	src := FromSlice(values)
+	return FromFuncWithClose(func(ctx Context) (int, bool) {
+		return src.Next()
+	}, func() error {
+		closed = true
+		return nil
+	}), &closed
+}
+
+type csvRow struct {
+	A int
+}
+
+func TestTerminalOpsCloseInput(t *testing.T) {
+	eq := func(v1 int, v2 int) (bool, error) { return v1 == v2, nil }
+	isOne := func(v int) (bool, error) { return v == 1, nil }
+	identity := func(v int) (int, error) { return v, nil }
+	add := func(v1 int, v2 int) (int, error) { return v1 + v2, nil }
+	zero := func() (int, error) { return 0, nil }
+	r := rand.New(rand.NewSource(1))
+
+	cases := []struct {
+		name string
+		two  bool
+		run  func(a Iter[int], b Iter[int])
+	}{
+		{"Len", false, func(a, b Iter[int]) { _, _ = Len(a) }},
+		{"Get", false, func(a, b Iter[int]) { _, _, _ = Get(a, 1) }},
+		{"GetOrElse", false, func(a, b Iter[int]) { _, _ = GetOrElse(a, 1, 0) }},
+		{"GetOrNil", false, func(a, b Iter[int]) { _, _ = GetOrNil(a, 1) }},
+		{"GetOrZero", false, func(a, b Iter[int]) { _, _ = GetOrZero(a, 1) }},
+		{"GetOrFunc", false, func(a, b Iter[int]) { _, _ = GetOrFunc(a, 1, zero) }},
+		{"GetFirst", false, func(a, b Iter[int]) { _, _, _ = GetFirst(a) }},
+		{"GetFirstOrElse", false, func(a, b Iter[int]) { _, _ = GetFirstOrElse(a, 0) }},
+		{"GetLast", false, func(a, b Iter[int]) { _, _, _ = GetLast(a) }},
+		{"GetLastOrElse", false, func(a, b Iter[int]) { _, _ = GetLastOrElse(a, 0) }},
+		{"ForEach", false, func(a, b Iter[int]) { _ = ForEach(a, func(int) error { return errTest }) }},
+		{"EqualBy", true, func(a, b Iter[int]) { _, _ = EqualBy(a, b, eq) }},
+		{"Equal", true, func(a, b Iter[int]) { _, _ = Equal(a, b) }},
+		{"CountBy", false, func(a, b Iter[int]) { _, _ = CountBy(a, isOne) }},
+		{"Count", false, func(a, b Iter[int]) { _, _ = Count(a, 1) }},
+		{"Reduce", false, func(a, b Iter[int]) { _, _ = Reduce(a, add) }},
+		{"Sum", false, func(a, b Iter[int]) { _, _ = Sum(a) }},
+		{"SumBy", false, func(a, b Iter[int]) { _, _ = SumBy(a, identity) }},
+		{"Max", false, func(a, b Iter[int]) { _, _ = Max(a) }},
+		{"MaxBy", false, func(a, b Iter[int]) { _, _ = MaxBy(a, identity) }},
+		{"Min", false, func(a, b Iter[int]) { _, _ = Min(a) }},
+		{"MinBy", false, func(a, b Iter[int]) { _, _ = MinBy(a, identity) }},
+		{"Fold", false, func(a, b Iter[int]) { _, _ = Fold(a, 0, add) }},
+		{"IndexBy", false, func(a, b Iter[int]) { _, _ = IndexBy(a, isOne) }},
+		{"Index", false, func(a, b Iter[int]) { _, _ = Index(a, 1) }},
+		{"LastIndexBy", false, func(a, b Iter[int]) { _, _ = LastIndexBy(a, isOne) }},
+		{"LastIndex", false, func(a, b Iter[int]) { _, _ = LastIndex(a, 1) }},
+		{"FindBy", false, func(a, b Iter[int]) { _, _, _ = FindBy(a, isOne) }},
+		{"Find", false, func(a, b Iter[int]) { _, _, _ = Find(a, 1) }},
+		{"ExistsBy", false, func(a, b Iter[int]) { _, _ = ExistsBy(a, isOne) }},
+		{"Exists", false, func(a, b Iter[int]) { _, _ = Exists(a, 1) }},
+		{"ForAllBy", false, func(a, b Iter[int]) { _, _ = ForAllBy(a, isOne) }},
+		{"ForAll", false, func(a, b Iter[int]) { _, _ = ForAll(a, 1) }},
+		{"ContainsAny", true, func(a, b Iter[int]) { _, _ = ContainsAny(a, b) }},
+		{"ContainsAll", true, func(a, b Iter[int]) { _, _ = ContainsAll(a, b) }},
+		{"StartsWith", true, func(a, b Iter[int]) { _, _ = StartsWith(a, b) }},
+		{"EndsWith", true, func(a, b Iter[int]) { _, _ = EndsWith(a, b) }},
+		{"PartitionBy", false, func(a, b Iter[int]) { _, _, _ = PartitionBy(a, isOne) }},
+		{"Partition", false, func(a, b Iter[int]) { _, _, _ = Partition(a, 1) }},
+		{"Unzip2", false, func(a, b Iter[int]) { _, _, _ = Unzip2(ZipWithIndex(a)) }},
+		{"Unzip3", false, func(a, b Iter[int]) {
+			_, _, _, _ = Unzip3(Map(a, func(v int) (tuple.T3[int, int, int], error) {
+				return tuple.NewT3(v, v, v), nil
+			}))
+		}},
+		{"Unzip4", false, func(a, b Iter[int]) {
+			_, _, _, _, _ = Unzip4(Map(a, func(v int) (tuple.T4[int, int, int, int], error) {
+				return tuple.NewT4(v, v, v, v), nil
+			}))
+		}},
+		{"Unzip5", false, func(a, b Iter[int]) {
+			_, _, _, _, _, _ = Unzip5(Map(a, func(v int) (tuple.T5[int, int, int, int, int], error) {
+				return tuple.NewT5(v, v, v, v, v), nil
+			}))
+		}},
+		{"Unzip6", false, func(a, b Iter[int]) {
+			_, _, _, _, _, _, _ = Unzip6(Map(a, func(v int) (tuple.T6[int, int, int, int, int, int], error) {
+				return tuple.NewT6(v, v, v, v, v, v), nil
+			}))
+		}},
+		{"GroupBy", false, func(a, b Iter[int]) { _, _ = GroupBy(a, identity) }},
+		{"ToSlice", false, func(a, b Iter[int]) { _, _ = ToSlice(a) }},
+		{"ToMap", false, func(a, b Iter[int]) { _, _ = ToMap(ZipWithIndex(a)) }},
+		{"ToPtr", false, func(a, b Iter[int]) { _, _ = ToPtr(a) }},
+		{"WriteJSON", false, func(a, b Iter[int]) { _ = WriteJSON(a, json.NewEncoder(io.Discard)) }},
+		{"WriteJSONArray", false, func(a, b Iter[int]) { _ = WriteJSONArray(a, io.Discard, nil) }},
+		{"SampleN", false, func(a, b Iter[int]) { _, _ = SampleN(a, 1, r) }},
+		{"WeightedSampleN", false, func(a, b Iter[int]) {
+			_, _ = WeightedSampleN(a, 1, func(v int) (float64, error) { return 1, nil }, r)
+		}},
+		{"WriteCSV", false, func(a, b Iter[int]) {
+			_ = WriteCSV(Map(a, func(v int) (csvRow, error) { return csvRow{A: v}, nil }), csv.NewWriter(io.Discard))
+		}},
+	}
+	for _, c := range cases {
+		a, closedA := trackClose(1, 2, 3, 4, 5)
+		b, closedB := trackClose(4, 5)
+		c.run(a, b)
+		if !*closedA {
+			t.Errorf("%s: input was not closed", c.name)
+		}
+		if c.two && !*closedB {
+			t.Errorf("%s: second input was not closed", c.name)
+		}
+	}
+}
+
+func TestGetFirstClosesInput(t *testing.T) {
+	iter, _ := trackClose(1, 2, 3)
+	if v, ok, err := GetFirst(iter); v != 1 || !ok || err != nil {
+		t.Fatalf("GetFirst() = %v, %v, %v", v, ok, err)
+	}
+	rest, err := ToSlice(iter)
+	if err != nil || len(rest) != 0 {
+		t.Fatalf("ToSlice() after GetFirst = %v, %v, want no values", rest, err)
+	}
+}
+
+// Close がエラーを返すイテレータ。
+type closeErrIter struct {
+	Iter[int]
+	err error
+}
+
+func (iter closeErrIter) Close() error {
+	return iter.err
+}
+
+func TestConcatReportsCloseError(t *testing.T) {
+	iter := Concat[int](closeErrIter{FromSlice([]int{1, 2}), errTest}, FromSlice([]int{3}))
+	got, err := ToSlice(iter)
+	if !errors.Is(err, errTest) {
+		t.Fatalf("got %v, %v, want %v", got, err, errTest)
+	}
+}
+
+func TestFlattenReportsCloseError(t *testing.T) {
+	iter := Flatten(FromSlice([]Iter[int]{
+		closeErrIter{FromSlice([]int{1, 2}), errTest},
+		FromSlice([]int{3}),
+	}))
+	got, err := ToSlice(iter)
+	if !errors.Is(err, errTest) {
+		t.Fatalf("got %v, %v, want %v", got, err, errTest)
+	}
+}
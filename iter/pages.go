@@ -0,0 +1,94 @@
+package iter
+
+import (
+	"context"
+
+	"github.com/thamaji/gu/retry"
+)
+
+// FromPages の設定。0 や nil の項目は使われない。
+type PagesOptions struct {
+	// ゴルーチンの中で先読みするページの数。
+	Prefetch int
+	// 取得するページの最大数。
+	MaxPages int
+	// ページの取得に失敗したときのリトライ戦略。
+	Retry retry.Strategy
+}
+
+func NewPagesOptions() *PagesOptions {
+	return &PagesOptions{
+		Prefetch: 0,
+		MaxPages: 0,
+		Retry:    nil,
+	}
+}
+
+func (opts *PagesOptions) WithPrefetch(prefetch int) *PagesOptions {
+	opts.Prefetch = prefetch
+	return opts
+}
+
+func (opts *PagesOptions) WithMaxPages(maxPages int) *PagesOptions {
+	opts.MaxPages = maxPages
+	return opts
+}
+
+func (opts *PagesOptions) WithRetry(s retry.Strategy) *PagesOptions {
+	opts.Retry = s
+	return opts
+}
+
+// ページごとに値を取得する関数からイテレータをつくる。
+// fetch はトークンを受け取り、ページの値と次のページのトークンを返す。最初のページはゼロ値のトークンで取得する。
+// 次のページのトークンがゼロ値になったら終了する。ページは値を読み進めたときに取得される。
+// ctx は fetch に渡され、イテレータを閉じたときにキャンセルされる。ctx が終了したら、再試行を待っている途中でも止まる。
+// opts が nil の場合は NewPagesOptions() を使う。
+func FromPages[V any, T comparable](ctx context.Context, fetch func(ctx context.Context, token T) ([]V, T, error), opts *PagesOptions) Iter[V] {
+	if opts == nil {
+		opts = NewPagesOptions()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+
+	var token T
+	count := 0
+	done := false
+	pages := FromFunc(func(c Context) ([]V, bool) {
+		if done || (opts.MaxPages > 0 && count >= opts.MaxPages) {
+			return nil, false
+		}
+		var values []V
+		var next T
+		var err error
+		if opts.Retry != nil {
+			values, next, err = retry.Run2Context(ctx, opts.Retry, func() ([]V, T, error) {
+				return fetch(ctx, token)
+			})
+		} else {
+			values, next, err = fetch(ctx, token)
+		}
+		if err != nil {
+			c.SetErr(err)
+			return nil, false
+		}
+		count++
+		token = next
+		done = next == *new(T)
+		return values, true
+	})
+	if opts.Prefetch > 0 {
+		pages = Prefetch(pages, opts.Prefetch)
+	}
+
+	values := flattenSlices(pages)
+	return FromFuncWithClose(func(c Context) (V, bool) {
+		v, ok := values.Next()
+		if !ok {
+			c.SetErr(values.Err())
+		}
+		return v, ok
+	}, func() error {
+		cancel()
+		return Close(values)
+	})
+}
@@ -0,0 +1,171 @@
+package iter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/thamaji/gu/retry"
+)
+
+// token 番目のページとして token*10, token*10+1 を返し、last 番目のページで終わる fetch。
+func stubPages(last int) func(context.Context, int) ([]int, int, error) {
+	return func(ctx context.Context, token int) ([]int, int, error) {
+		next := token + 1
+		if token >= last {
+			next = 0
+		}
+		return []int{token * 10, token*10 + 1}, next, nil
+	}
+}
+
+func TestFromPages(t *testing.T) {
+	got, err := ToSlice(FromPages(context.Background(), stubPages(3), nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{0, 1, 10, 11, 20, 21, 30, 31}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestFromPagesMaxPages(t *testing.T) {
+	calls := 0
+	fetch := stubPages(100)
+	got, err := ToSlice(FromPages(context.Background(), func(ctx context.Context, token int) ([]int, int, error) {
+		calls++
+		return fetch(ctx, token)
+	}, NewPagesOptions().WithMaxPages(2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 4 || calls != 2 {
+		t.Fatalf("got %v with %d calls, want 4 values with 2 calls", got, calls)
+	}
+}
+
+func TestFromPagesRetry(t *testing.T) {
+	failures := map[int]int{}
+	fetch := stubPages(2)
+	flaky := func(ctx context.Context, token int) ([]int, int, error) {
+		if failures[token] < 2 {
+			failures[token]++
+			return nil, 0, errTest
+		}
+		return fetch(ctx, token)
+	}
+
+	got, err := ToSlice(FromPages(context.Background(), flaky, NewPagesOptions().WithRetry(retry.Simple().WithMaxRetry(2))))
+	if err != nil || len(got) != 6 {
+		t.Fatalf("got %v, %v", got, err)
+	}
+
+	failures = map[int]int{}
+	_, err = ToSlice(FromPages(context.Background(), flaky, NewPagesOptions().WithRetry(retry.Simple().WithMaxRetry(1))))
+	if !errors.Is(err, errTest) {
+		t.Fatalf("err = %v, want %v", err, errTest)
+	}
+
+	failures = map[int]int{}
+	_, err = ToSlice(FromPages(context.Background(), flaky, nil))
+	if !errors.Is(err, errTest) {
+		t.Fatalf("err = %v, want %v without retry", err, errTest)
+	}
+}
+
+func TestFromPagesPrefetch(t *testing.T) {
+	var mu sync.Mutex
+	fetched := 0
+	fetch := stubPages(9)
+	iter := FromPages(context.Background(), func(ctx context.Context, token int) ([]int, int, error) {
+		mu.Lock()
+		fetched++
+		mu.Unlock()
+		return fetch(ctx, token)
+	}, NewPagesOptions().WithPrefetch(2))
+
+	if v, ok := iter.Next(); !ok || v != 0 {
+		t.Fatalf("Next() = %v, %v", v, ok)
+	}
+	got, err := ToSlice(iter)
+	if err != nil || len(got) != 19 {
+		t.Fatalf("got %d values, %v", len(got), err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if fetched != 10 {
+		t.Fatalf("fetched %d pages, want 10", fetched)
+	}
+}
+
+func TestFromPagesCloseStopsRetry(t *testing.T) {
+	started := make(chan struct{}, 1)
+	fetch := func(ctx context.Context, token int) ([]int, int, error) {
+		if token == 0 {
+			return []int{1}, 1, nil
+		}
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-ctx.Done()
+		return nil, 0, ctx.Err()
+	}
+	opts := NewPagesOptions().
+		WithPrefetch(1).
+		WithRetry(retry.Simple().WithMaxRetry(3).WithDelay(time.Second))
+	iter := FromPages(context.Background(), fetch, opts)
+	if _, ok := iter.Next(); !ok {
+		t.Fatal(iter.Err())
+	}
+	<-started
+
+	start := time.Now()
+	if err := Close(iter); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Fatalf("Close took %v", d)
+	}
+}
+
+func TestFromPagesCloseStopsRetryWait(t *testing.T) {
+	strategies := []retry.Strategy{
+		retry.Simple().WithMaxRetry(3).WithDelay(2 * time.Second),
+		retry.ExponentialBackoff(),
+	}
+	for _, s := range strategies {
+		failed := make(chan struct{}, 1)
+		fetch := func(ctx context.Context, token int) ([]int, int, error) {
+			if token == 0 {
+				return []int{1}, 1, nil
+			}
+			select {
+			case failed <- struct{}{}:
+			default:
+			}
+			return nil, 0, errTest
+		}
+		iter := FromPages(context.Background(), fetch, NewPagesOptions().WithPrefetch(1).WithRetry(s))
+		if _, ok := iter.Next(); !ok {
+			t.Fatal(iter.Err())
+		}
+		<-failed
+
+		start := time.Now()
+		if err := Close(iter); err != nil {
+			t.Fatal(err)
+		}
+		if d := time.Since(start); d > 500*time.Millisecond {
+			t.Fatalf("Close took %v", d)
+		}
+	}
+}
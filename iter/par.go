@@ -0,0 +1,255 @@
+package iter
+
+import (
+	"sync"
+
+	"github.com/thamaji/gu/errs"
+	"github.com/thamaji/gu/must"
+)
+
+// n個のゴルーチンで並列に値を変換したイテレータを返す。値の順序は保たれる。
+// エラーが起きた場合、Err は停止するまでに起きたエラーを値の位置とともにまとめた *errs.Errors を返す。
+// 途中で読むのをやめる場合は Close を呼ぶこと。
+func ParMap[V1 any, V2 any](iter Iter[V1], n int, f func(V1) (V2, error)) Iter[V2] {
+	return parCollect(iter, n, true, func(v V1) (V2, bool, error) {
+		v2, err := f(v)
+		return v2, true, err
+	})
+}
+
+// n個のゴルーチンで並列に値を変換したイテレータを返す。値は変換の終わった順に返される。
+// 途中で読むのをやめる場合は Close を呼ぶこと。
+func ParMapUnordered[V1 any, V2 any](iter Iter[V1], n int, f func(V1) (V2, error)) Iter[V2] {
+	return parCollect(iter, n, false, func(v V1) (V2, bool, error) {
+		v2, err := f(v)
+		return v2, true, err
+	})
+}
+
+// n個のゴルーチンで並列に条件を判定し、条件を満たす値だけのイテレータを返す。値の順序は保たれる。
+// 途中で読むのをやめる場合は Close を呼ぶこと。
+func ParFilterBy[V any](iter Iter[V], n int, f func(V) (bool, error)) Iter[V] {
+	return parCollect(iter, n, true, func(v V) (V, bool, error) {
+		ok, err := f(v)
+		return v, ok, err
+	})
+}
+
+// n個のゴルーチンで並列に条件を判定し、条件を満たす値だけのイテレータを返す。値は判定の終わった順に返される。
+// 途中で読むのをやめる場合は Close を呼ぶこと。
+func ParFilterByUnordered[V any](iter Iter[V], n int, f func(V) (bool, error)) Iter[V] {
+	return parCollect(iter, n, false, func(v V) (V, bool, error) {
+		ok, err := f(v)
+		return v, ok, err
+	})
+}
+
+// n個のゴルーチンで並列に値をイテレータに変換し、それらを結合したイテレータを返す。値の順序は保たれる。
+// 変換したイテレータはゴルーチンの中で読み切られる。
+// 途中で読むのをやめる場合は Close を呼ぶこと。
+func ParFlatMap[V1 any, V2 any](iter Iter[V1], n int, f func(V1) (Iter[V2], error)) Iter[V2] {
+	return flattenSlices(ParMap(iter, n, toSliceFunc(f)))
+}
+
+// n個のゴルーチンで並列に値をイテレータに変換し、それらを結合したイテレータを返す。値は変換の終わった順に返される。
+// 変換したイテレータはゴルーチンの中で読み切られる。
+// 途中で読むのをやめる場合は Close を呼ぶこと。
+func ParFlatMapUnordered[V1 any, V2 any](iter Iter[V1], n int, f func(V1) (Iter[V2], error)) Iter[V2] {
+	return flattenSlices(ParMapUnordered(iter, n, toSliceFunc(f)))
+}
+
+// n個のゴルーチンで並列に値ごとに関数を実行する。
+// エラーが起きた場合は、停止するまでに起きたエラーを値の位置とともにまとめた *errs.Errors を返す。
+func ParForEach[V any](iter Iter[V], n int, f func(V) error) error {
+	return ForEach(parCollect(iter, n, false, func(v V) (struct{}, bool, error) {
+		return struct{}{}, false, f(v)
+	}), func(struct{}) error { return nil })
+}
+
+// n個のゴルーチンで並列に値ごとに関数を実行する。実行中にエラーが起きた場合 panic する。
+func MustParForEach[V any](iter Iter[V], n int, f func(V) error) {
+	must.Must0(ParForEach(iter, n, f))
+}
+
+func toSliceFunc[V1 any, V2 any](f func(V1) (Iter[V2], error)) func(V1) ([]V2, error) {
+	return func(v V1) ([]V2, error) {
+		sub, err := f(v)
+		if err != nil {
+			return nil, err
+		}
+		return ToSlice(sub)
+	}
+}
+
+func flattenSlices[V any](iter Iter[[]V]) Iter[V] {
+	return Flatten(Map(iter, func(slice []V) (Iter[V], error) {
+		return FromSlice(slice), nil
+	}))
+}
+
+type parResult[V any] struct {
+	v   V
+	ok  bool
+	err error
+}
+
+type parJob[V1 any, V2 any] struct {
+	index int
+	v     V1
+	out   chan parResult[V2]
+}
+
+// 並列処理の状態。エラーが起きたらすべてのゴルーチンを停止させる。
+// 停止するまでに起きたエラーは、値の位置とともにすべて記録する。
+type parState struct {
+	errs *errs.Errors
+	done chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+func (s *parState) fail(index int, err error) {
+	s.errs.Add(index, err)
+	s.stop()
+}
+
+func (s *parState) stop() {
+	s.once.Do(func() { close(s.done) })
+}
+
+func (s *parState) Err() error {
+	return s.errs.ErrOrNil()
+}
+
+// n個のゴルーチンで並列に関数を実行し、第２戻り値が true の値だけのイテレータを返す。
+// ordered が true のとき値の順序は保たれる。
+func parCollect[V1 any, V2 any](iter Iter[V1], n int, ordered bool, f func(V1) (V2, bool, error)) Iter[V2] {
+	if n < 1 {
+		n = 1
+	}
+
+	s := &parState{errs: errs.New(), done: make(chan struct{})}
+	jobs := make(chan parJob[V1, V2], n)
+	order := make(chan chan parResult[V2], n)
+	results := make(chan parResult[V2], n)
+
+	// ゴルーチンは最初に読んだときに開始する。
+	var once sync.Once
+	started := false
+	start := func() {
+		started = true
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer close(jobs)
+			defer close(order)
+			defer func() { _ = Close(iter) }()
+			for i := 0; ; i++ {
+				v, ok := iter.Next()
+				if !ok {
+					if err := iter.Err(); err != nil {
+						s.fail(i, err)
+					}
+					return
+				}
+				job := parJob[V1, V2]{index: i, v: v}
+				if ordered {
+					job.out = make(chan parResult[V2], 1)
+					select {
+					case order <- job.out:
+					case <-s.done:
+						return
+					}
+				}
+				select {
+				case jobs <- job:
+				case <-s.done:
+					return
+				}
+			}
+		}()
+
+		var workers sync.WaitGroup
+		workers.Add(n)
+		for i := 0; i < n; i++ {
+			go func() {
+				defer workers.Done()
+				for job := range jobs {
+					select {
+					case <-s.done:
+						continue
+					default:
+					}
+					v, ok, err := f(job.v)
+					if err != nil {
+						s.fail(job.index, err)
+					}
+					r := parResult[V2]{v: v, ok: ok, err: err}
+					if ordered {
+						job.out <- r
+						continue
+					}
+					select {
+					case results <- r:
+					case <-s.done:
+					}
+				}
+			}()
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			workers.Wait()
+			close(results)
+		}()
+	}
+
+	receive := func() (parResult[V2], bool) {
+		if !ordered {
+			select {
+			case r, ok := <-results:
+				return r, ok
+			case <-s.done:
+				return parResult[V2]{}, false
+			}
+		}
+		select {
+		case out, ok := <-order:
+			if !ok {
+				return parResult[V2]{}, false
+			}
+			select {
+			case r := <-out:
+				return r, true
+			case <-s.done:
+				return parResult[V2]{}, false
+			}
+		case <-s.done:
+			return parResult[V2]{}, false
+		}
+	}
+
+	return FromFuncWithClose(func(ctx Context) (V2, bool) {
+		once.Do(start)
+		for {
+			r, ok := receive()
+			if !ok || r.err != nil {
+				ctx.SetErr(s.Err())
+				return *new(V2), false
+			}
+			if !r.ok {
+				continue
+			}
+			return r.v, true
+		}
+	}, func() error {
+		once.Do(func() {})
+		if !started {
+			return Close(iter)
+		}
+		s.stop()
+		s.wg.Wait()
+		return nil
+	})
+}
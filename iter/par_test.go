@@ -0,0 +1,177 @@
+package iter
+
+import (
+	"errors"
+	"runtime"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/thamaji/gu/errs"
+)
+
+// 値によって変換にかかる時間を変え、終わる順序を入れ替える。
+func slowDouble(v int) (int, error) {
+	time.Sleep(time.Duration(v%4) * time.Millisecond)
+	return v * 2, nil
+}
+
+func TestParMap(t *testing.T) {
+	got, err := ToSlice(ParMap(Range(0, 100, 1), 4, slowDouble))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 100 {
+		t.Fatalf("got %d values, want 100", len(got))
+	}
+	for i, v := range got {
+		if v != i*2 {
+			t.Fatalf("got[%d] = %d, want %d", i, v, i*2)
+		}
+	}
+}
+
+func TestParMapUnordered(t *testing.T) {
+	got, err := ToSlice(ParMapUnordered(Range(0, 100, 1), 4, slowDouble))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Ints(got)
+	if len(got) != 100 {
+		t.Fatalf("got %d values, want 100", len(got))
+	}
+	for i, v := range got {
+		if v != i*2 {
+			t.Fatalf("got[%d] = %d, want %d", i, v, i*2)
+		}
+	}
+}
+
+func TestParFilterBy(t *testing.T) {
+	got, err := ToSlice(ParFilterBy(Range(0, 20, 1), 4, func(v int) (bool, error) {
+		time.Sleep(time.Duration(v%3) * time.Millisecond)
+		return v%2 == 0, nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{0, 2, 4, 6, 8, 10, 12, 14, 16, 18}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestParMapError(t *testing.T) {
+	_, err := ToSlice(ParMap(Range(0, 100, 1), 4, func(v int) (int, error) {
+		if v == 10 {
+			return 0, errTest
+		}
+		return v, nil
+	}))
+	if !errors.Is(err, errTest) {
+		t.Fatalf("err = %v, want %v", err, errTest)
+	}
+	var es *errs.Errors
+	if !errors.As(err, &es) {
+		t.Fatalf("err = %T, want *errs.Errors", err)
+	}
+	if e := es.Errs()[0]; e.Index != 10 {
+		t.Fatalf("index = %d, want 10", e.Index)
+	}
+}
+
+func TestParMapSourceError(t *testing.T) {
+	_, err := ToSlice(ParMap(failAfter(5), 4, slowDouble))
+	if !errors.Is(err, errTest) {
+		t.Fatalf("err = %v, want %v", err, errTest)
+	}
+}
+
+func TestParForEach(t *testing.T) {
+	var sum int64
+	err := ParForEach(Range(1, 101, 1), 4, func(v int) error {
+		atomic.AddInt64(&sum, int64(v))
+		return nil
+	})
+	if err != nil || sum != 5050 {
+		t.Fatalf("sum = %d, err = %v", sum, err)
+	}
+
+	err = ParForEach(Range(0, 100, 1), 4, func(v int) error {
+		if v%10 == 9 {
+			return errTest
+		}
+		return nil
+	})
+	if !errors.Is(err, errTest) {
+		t.Fatalf("err = %v, want %v", err, errTest)
+	}
+}
+
+func TestParMapClose(t *testing.T) {
+	for _, ordered := range []bool{true, false} {
+		var closed int32
+		r := Range(0, 1000000, 1)
+		src := FromFuncWithClose(func(ctx Context) (int, bool) {
+			return r.Next()
+		}, func() error {
+			atomic.StoreInt32(&closed, 1)
+			return nil
+		})
+		iter := parCollect(src, 4, ordered, func(v int) (int, bool, error) {
+			return v, true, nil
+		})
+		for i := 0; i < 3; i++ {
+			if _, ok := iter.Next(); !ok {
+				t.Fatal(iter.Err())
+			}
+		}
+		if err := Close(iter); err != nil {
+			t.Fatal(err)
+		}
+		if atomic.LoadInt32(&closed) != 1 {
+			t.Fatalf("ordered=%v: source was not closed", ordered)
+		}
+	}
+}
+
+func TestParMapLazy(t *testing.T) {
+	for _, ordered := range []bool{true, false} {
+		var reads, calls, closed int32
+		r := Range(0, 100, 1)
+		src := FromFuncWithClose(func(ctx Context) (int, bool) {
+			atomic.AddInt32(&reads, 1)
+			return r.Next()
+		}, func() error {
+			atomic.StoreInt32(&closed, 1)
+			return nil
+		})
+		goroutines := runtime.NumGoroutine()
+		iter := parCollect(src, 4, ordered, func(v int) (int, bool, error) {
+			atomic.AddInt32(&calls, 1)
+			return v, true, nil
+		})
+		time.Sleep(10 * time.Millisecond)
+		if n := runtime.NumGoroutine(); n > goroutines {
+			t.Fatalf("ordered=%v: %d goroutines started before Next", ordered, n-goroutines)
+		}
+		if atomic.LoadInt32(&reads) != 0 || atomic.LoadInt32(&calls) != 0 {
+			t.Fatalf("ordered=%v: source read before Next", ordered)
+		}
+		if err := Close(iter); err != nil {
+			t.Fatal(err)
+		}
+		if atomic.LoadInt32(&closed) != 1 {
+			t.Fatalf("ordered=%v: source was not closed", ordered)
+		}
+		if atomic.LoadInt32(&reads) != 0 {
+			t.Fatalf("ordered=%v: source read after Close", ordered)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package iter
+
+// 先の値を覗いたり、読んだ値を戻したりできるイテレータ。
+type Peekable[V any] struct {
+	iter Iter[V]
+	buf  []V // 戻された値。末尾が次に返す値
+}
+
+// 先の値を覗いたり、読んだ値を戻したりできるイテレータをつくる。
+// iter が Peekable の場合はそのまま返す。
+func NewPeekable[V any](iter Iter[V]) *Peekable[V] {
+	if p, ok := iter.(*Peekable[V]); ok {
+		return p
+	}
+	return &Peekable[V]{iter: iter}
+}
+
+func (p *Peekable[V]) Next() (V, bool) {
+	if n := len(p.buf); n > 0 {
+		v := p.buf[n-1]
+		p.buf[n-1] = *new(V)
+		p.buf = p.buf[:n-1]
+		return v, true
+	}
+	return p.iter.Next()
+}
+
+func (p *Peekable[V]) Err() error {
+	return p.iter.Err()
+}
+
+func (p *Peekable[V]) Close() error {
+	p.buf = nil
+	return Close(p.iter)
+}
+
+// 次の値を読まずに返す。
+func (p *Peekable[V]) Peek() (V, bool) {
+	if n := len(p.buf); n > 0 {
+		return p.buf[n-1], true
+	}
+	v, ok := p.iter.Next()
+	if !ok {
+		return *new(V), false
+	}
+	p.buf = append(p.buf, v)
+	return v, true
+}
+
+// 次のn個までの値を読まずに返す。終端に達した場合は残りの値だけを返す。
+func (p *Peekable[V]) PeekN(n int) []V {
+	for len(p.buf) < n {
+		v, ok := p.iter.Next()
+		if !ok {
+			break
+		}
+		// 読んだ値は戻された値より後に返すので、スタックの底に入れる。
+		p.buf = append(p.buf, v)
+		copy(p.buf[1:], p.buf[:len(p.buf)-1])
+		p.buf[0] = v
+	}
+	if n > len(p.buf) {
+		n = len(p.buf)
+	}
+	values := make([]V, n)
+	for i := range values {
+		values[i] = p.buf[len(p.buf)-1-i]
+	}
+	return values
+}
+
+// 値を戻す。戻した値は v の順に、元の値より先に返される。
+func (p *Peekable[V]) Unread(v ...V) {
+	for i := len(v) - 1; i >= 0; i-- {
+		p.buf = append(p.buf, v[i])
+	}
+}
+
+// 次の値が条件を満たす場合だけ、その値を読んで返す。
+func (p *Peekable[V]) NextIf(f func(V) (bool, error)) (V, bool, error) {
+	v, ok := p.Peek()
+	if !ok {
+		return *new(V), false, nil
+	}
+	ok, err := f(v)
+	if err != nil || !ok {
+		return *new(V), false, err
+	}
+	v, ok = p.Next()
+	return v, ok, nil
+}
@@ -0,0 +1,221 @@
+package iter
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/thamaji/gu/errs"
+)
+
+// 値ごとのエラーの扱い方。
+// 失敗した値とエラーを受け取り、nil を返した場合はその値を飛ばして続け、エラーを返した場合はそのエラーで停止する。
+type ErrorPolicy[V any] func(V, error) error
+
+// エラーが起きたら停止する。
+func StopOnError[V any]() ErrorPolicy[V] {
+	return func(v V, err error) error {
+		return err
+	}
+}
+
+// エラーが起きた値を飛ばして続ける。エラーは summary に記録される。summary は nil でもよい。
+func SkipOnError[V any](summary *ErrorSummary) ErrorPolicy[V] {
+	return func(v V, err error) error {
+		summary.Add(err)
+		return nil
+	}
+}
+
+// エラーが起きた値とエラーを sink に渡して続ける。エラーは summary にも記録される。summary は nil でもよい。
+// sink がエラーを返した場合は停止する。
+func DeadLetterOnError[V any](sink func(V, error) error, summary *ErrorSummary) ErrorPolicy[V] {
+	return func(v V, err error) error {
+		summary.Add(err)
+		return sink(v, err)
+	}
+}
+
+// 飛ばしたエラーの集計。エラーには記録した順の番号がつく。複数のゴルーチンから使ってもよい。
+// ゼロ値はエラーを保持せず、数だけを数える。
+type ErrorSummary struct {
+	mu        sync.Mutex
+	maxErrors int
+	count     int
+	errs      errs.Errors
+}
+
+// 最初の maxErrors 個までのエラーを保持する集計をつくる。
+func NewErrorSummary(maxErrors int) *ErrorSummary {
+	return &ErrorSummary{maxErrors: maxErrors}
+}
+
+// エラーを記録する。s が nil の場合は何もしない。
+func (s *ErrorSummary) Add(err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.count < s.maxErrors {
+		s.errs.Add(s.count, err)
+	}
+	s.count++
+}
+
+// 記録したエラーの数を返す。
+func (s *ErrorSummary) Count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.count
+}
+
+// 保持しているエラーを返す。
+func (s *ErrorSummary) Errors() []*errs.Error {
+	return s.errs.Errs()
+}
+
+// 記録したエラーをまとめたエラーを返す。エラーが無い場合は nil を返す。
+func (s *ErrorSummary) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.count == 0 {
+		return nil
+	}
+	es := errs.New()
+	for _, err := range s.errs.Errs() {
+		es.Add(err.Index, err.Err)
+	}
+	return &SkippedError{Count: s.count, Errs: es}
+}
+
+// 飛ばしたエラーをまとめたエラー。
+type SkippedError struct {
+	// 飛ばしたエラーの数。
+	Count int
+	// 保持しているエラー。
+	Errs *errs.Errors
+}
+
+func (e *SkippedError) Error() string {
+	if e.Errs.Len() == 0 {
+		return fmt.Sprintf("iter: %d errors skipped", e.Count)
+	}
+	return fmt.Sprintf("iter: %d errors skipped: %v", e.Count, e.Errs)
+}
+
+func (e *SkippedError) Unwrap() error {
+	return e.Errs
+}
+
+// 値を変換したイテレータを返す。関数がエラーを返した場合は policy に従う。
+// policy が nil の場合は StopOnError() を使う。
+func MapWithPolicy[V1 any, V2 any](iter Iter[V1], f func(V1) (V2, error), policy ErrorPolicy[V1]) Iter[V2] {
+	return CollectWithPolicy(iter, func(v1 V1) (V2, bool, error) {
+		v2, err := f(v1)
+		return v2, true, err
+	}, policy)
+}
+
+// 条件を満たす値だけのイテレータを返す。関数がエラーを返した場合は policy に従う。
+// policy が nil の場合は StopOnError() を使う。
+func FilterByWithPolicy[V any](iter Iter[V], f func(V) (bool, error), policy ErrorPolicy[V]) Iter[V] {
+	return CollectWithPolicy(iter, func(v V) (V, bool, error) {
+		ok, err := f(v)
+		return v, ok, err
+	}, policy)
+}
+
+// 条件を満たす値を変換したイテレータを返す。関数がエラーを返した場合は policy に従う。
+// policy が nil の場合は StopOnError() を使う。
+func CollectWithPolicy[V1 any, V2 any](iter Iter[V1], f func(V1) (V2, bool, error), policy ErrorPolicy[V1]) Iter[V2] {
+	if policy == nil {
+		policy = StopOnError[V1]()
+	}
+	return FromFuncWithClose(func(ctx Context) (V2, bool) {
+		for {
+			v1, ok := iter.Next()
+			if !ok {
+				ctx.SetErr(iter.Err())
+				return *new(V2), false
+			}
+
+			v2, ok, err := f(v1)
+			if err != nil {
+				if err := policy(v1, err); err != nil {
+					ctx.SetErr(err)
+					return *new(V2), false
+				}
+				continue
+			}
+			if !ok {
+				continue
+			}
+			return v2, true
+		}
+	}, closer(iter))
+}
+
+// json.Decoder からイテレータをつくる。
+// 値の型が合わないなど、続きを読める値のエラーは、値の JSON とともに policy に渡す。
+// 構文エラーなど続きを読めないエラーでは停止する。
+// policy が nil の場合は StopOnError() を使う。
+func FromJSONWithPolicy[V any](decoder *json.Decoder, policy ErrorPolicy[json.RawMessage]) Iter[V] {
+	if policy == nil {
+		policy = StopOnError[json.RawMessage]()
+	}
+	return FromFunc(func(ctx Context) (V, bool) {
+		for {
+			if !decoder.More() {
+				return *new(V), false
+			}
+			raw := json.RawMessage{}
+			if err := decoder.Decode(&raw); err != nil {
+				ctx.SetErr(err)
+				return *new(V), false
+			}
+			v := *new(V)
+			if err := json.Unmarshal(raw, &v); err != nil {
+				if err := policy(raw, err); err != nil {
+					ctx.SetErr(err)
+					return *new(V), false
+				}
+				continue
+			}
+			return v, true
+		}
+	})
+}
+
+// csv.Reader からイテレータをつくる。
+// 列の数が合わないなど、続きを読める行のエラー（*csv.ParseError）は、読めた分の行とともに policy に渡す。
+// policy が nil の場合は StopOnError() を使う。
+func FromCSVWithPolicy(r *csv.Reader, policy ErrorPolicy[[]string]) Iter[[]string] {
+	if policy == nil {
+		policy = StopOnError[[]string]()
+	}
+	return FromFunc(func(ctx Context) ([]string, bool) {
+		for {
+			record, err := r.Read()
+			if err != nil {
+				if err == io.EOF {
+					return nil, false
+				}
+				var perr *csv.ParseError
+				if !errors.As(err, &perr) {
+					ctx.SetErr(err)
+					return nil, false
+				}
+				if err := policy(record, err); err != nil {
+					ctx.SetErr(err)
+					return nil, false
+				}
+				continue
+			}
+			return record, true
+		}
+	})
+}
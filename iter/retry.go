@@ -0,0 +1,80 @@
+package iter
+
+import "github.com/thamaji/gu/retry"
+
+// 値を変換したイテレータを返す。関数がエラーを返した場合は、値ごとにリトライ戦略に従って再試行する。
+// s が nil の場合は retry の既定の戦略を使う。
+func MapRetry[V1 any, V2 any](iter Iter[V1], s retry.Strategy, f func(V1) (V2, error)) Iter[V2] {
+	return Map(iter, func(v V1) (V2, error) {
+		return retry.Run1(s, func() (V2, error) { return f(v) })
+	})
+}
+
+// 条件を満たす値だけのイテレータを返す。関数がエラーを返した場合は、値ごとにリトライ戦略に従って再試行する。
+// s が nil の場合は retry の既定の戦略を使う。
+func FilterByRetry[V any](iter Iter[V], s retry.Strategy, f func(V) (bool, error)) Iter[V] {
+	return FilterBy(iter, func(v V) (bool, error) {
+		return retry.Run1(s, func() (bool, error) { return f(v) })
+	})
+}
+
+// 途中から読み直せるイテレータをつくる。
+// open は offset 番目（0 始まり）の値から読むイテレータを開く。
+// 開くときや読むときに一時的なエラーが起きた場合は、イテレータを閉じ、返した値の数を offset として開き直す。
+// 開き直しはリトライ戦略に従って再試行する。
+// transient はエラーが一時的なものか判定する。nil の場合はすべてのエラーを一時的なものとみなす。
+// s が nil の場合は retry の既定の戦略を使う。
+func FromResumable[V any](open func(offset int) (Iter[V], error), s retry.Strategy, transient func(error) bool) Iter[V] {
+	if transient == nil {
+		transient = func(error) bool { return true }
+	}
+	var current Iter[V]
+	offset := 0
+	return FromFuncWithClose(func(ctx Context) (V, bool) {
+		var fatal error
+		v, ok, err := retry.Run2(s, func() (V, bool, error) {
+			if current == nil {
+				iter, err := open(offset)
+				if err != nil {
+					if !transient(err) {
+						fatal = err
+						return *new(V), false, nil
+					}
+					return *new(V), false, err
+				}
+				current = iter
+			}
+			v, ok := current.Next()
+			if ok {
+				return v, true, nil
+			}
+			err := current.Err()
+			if err == nil {
+				return *new(V), false, nil
+			}
+			_ = Close(current)
+			current = nil
+			if !transient(err) {
+				fatal = err
+				return *new(V), false, nil
+			}
+			return *new(V), false, err
+		})
+		if err == nil {
+			err = fatal
+		}
+		if err != nil {
+			ctx.SetErr(err)
+			return *new(V), false
+		}
+		if ok {
+			offset++
+		}
+		return v, ok
+	}, func() error {
+		if current == nil {
+			return nil
+		}
+		return Close(current)
+	})
+}
@@ -0,0 +1,124 @@
+package iter
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+
+	"github.com/thamaji/gu/must"
+)
+
+// 値をランダムに最大k個選んで返す（リザーバサンプリング）。
+// 値の数が k 以下の場合はすべての値を返す。選んだ値の順序は保証しない。
+// iter は閉じられるので、読み残した値はあとから読めない。
+func SampleN[V any](iter Iter[V], k int, r *rand.Rand) ([]V, error) {
+	defer Close(iter)
+	if k <= 0 {
+		return []V{}, nil
+	}
+	reservoir := make([]V, 0, k)
+	n := 0
+	for {
+		v, ok := iter.Next()
+		if !ok {
+			break
+		}
+		n++
+		if len(reservoir) < k {
+			reservoir = append(reservoir, v)
+			continue
+		}
+		if i := r.Intn(n); i < k {
+			reservoir[i] = v
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return reservoir, nil
+}
+
+// 値をランダムに最大k個選んで返す（リザーバサンプリング）。実行中にエラーが起きた場合 panic する。
+func MustSampleN[V any](iter Iter[V], k int, r *rand.Rand) []V {
+	return must.Must1(SampleN(iter, k, r))
+}
+
+// 関数の返す重みに比例した確率で、値をランダムに最大k個選んで返す（A-Res）。
+// 重みが 0 以下の値は選ばれない。選んだ値の順序は保証しない。
+// iter は閉じられるので、読み残した値はあとから読めない。
+func WeightedSampleN[V any](iter Iter[V], k int, weight func(V) (float64, error), r *rand.Rand) ([]V, error) {
+	defer Close(iter)
+	h := &sampleHeap[V]{}
+	for {
+		v, ok := iter.Next()
+		if !ok {
+			break
+		}
+		w, err := weight(v)
+		if err != nil {
+			return nil, err
+		}
+		if w <= 0 || k <= 0 {
+			continue
+		}
+		key := math.Pow(r.Float64(), 1/w)
+		if h.Len() < k {
+			heap.Push(h, sampleItem[V]{key: key, value: v})
+		} else if key > (*h)[0].key {
+			(*h)[0] = sampleItem[V]{key: key, value: v}
+			heap.Fix(h, 0)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	values := make([]V, h.Len())
+	for i, item := range *h {
+		values[i] = item.value
+	}
+	return values, nil
+}
+
+// 関数の返す重みに比例した確率で、値をランダムに最大k個選んで返す（A-Res）。実行中にエラーが起きた場合 panic する。
+func MustWeightedSampleN[V any](iter Iter[V], k int, weight func(V) (float64, error), r *rand.Rand) []V {
+	return must.Must1(WeightedSampleN(iter, k, weight, r))
+}
+
+// それぞれの値を確率pで選んだイテレータを返す（ベルヌーイサンプリング）。
+func Bernoulli[V any](iter Iter[V], p float64, r *rand.Rand) Iter[V] {
+	return FromFuncWithClose(func(ctx Context) (V, bool) {
+		for {
+			v, ok := iter.Next()
+			if !ok {
+				ctx.SetErr(iter.Err())
+				return *new(V), false
+			}
+			if r.Float64() < p {
+				return v, true
+			}
+		}
+	}, closer(iter))
+}
+
+type sampleItem[V any] struct {
+	key   float64
+	value V
+}
+
+// key の小さい順に並ぶヒープ。
+type sampleHeap[V any] []sampleItem[V]
+
+func (h sampleHeap[V]) Len() int           { return len(h) }
+func (h sampleHeap[V]) Less(i, j int) bool { return h[i].key < h[j].key }
+func (h sampleHeap[V]) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *sampleHeap[V]) Push(x any) {
+	*h = append(*h, x.(sampleItem[V]))
+}
+
+func (h *sampleHeap[V]) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,96 @@
+//go:build go1.23
+
+package iter
+
+import (
+	stditer "iter"
+)
+
+// イテレータから iter.Seq をつくる。
+// 実行中に起きたエラーは、ループを抜けたあとに元のイテレータの Err で確認すること。
+func ToSeq[V any](iter Iter[V]) stditer.Seq[V] {
+	return func(yield func(V) bool) {
+		defer Close(iter)
+		for {
+			v, ok := iter.Next()
+			if !ok || !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// イテレータから iter.Seq2 をつくる。
+// 実行中にエラーが起きた場合は、最後にゼロ値とエラーの組を返す。
+func ToSeq2[V any](iter Iter[V]) stditer.Seq2[V, error] {
+	return func(yield func(V, error) bool) {
+		defer Close(iter)
+		for {
+			v, ok := iter.Next()
+			if !ok {
+				if err := iter.Err(); err != nil {
+					yield(*new(V), err)
+				}
+				return
+			}
+			if !yield(v, nil) {
+				return
+			}
+		}
+	}
+}
+
+// iter.Seq からイテレータをつくる。
+// 途中で読むのをやめる場合は Close を呼ぶこと。
+func FromSeq[V any](seq stditer.Seq[V]) Iter[V] {
+	next, stop := stditer.Pull(seq)
+	return FromFuncWithClose(func(ctx Context) (V, bool) {
+		return next()
+	}, func() error {
+		stop()
+		return nil
+	})
+}
+
+// iter.Seq2 からイテレータをつくる。
+// エラーの組を受け取ったら停止し、Err はそのエラーを返す。
+// 途中で読むのをやめる場合は Close を呼ぶこと。
+func FromSeq2[V any](seq stditer.Seq2[V, error]) Iter[V] {
+	next, stop := stditer.Pull2(seq)
+	return FromFuncWithClose(func(ctx Context) (V, bool) {
+		v, err, ok := next()
+		if !ok {
+			return *new(V), false
+		}
+		if err != nil {
+			ctx.SetErr(err)
+			return *new(V), false
+		}
+		return v, true
+	}, func() error {
+		stop()
+		return nil
+	})
+}
+
+// 位置と値の iter.Seq2 を返す。
+func (slice Slice[V]) All() stditer.Seq2[int, V] {
+	return func(yield func(int, V) bool) {
+		for i, v := range slice {
+			if !yield(i, v) {
+				return
+			}
+		}
+	}
+}
+
+// キーと値の iter.Seq2 を返す。
+func (m HashMap[K, V]) All() stditer.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for k, v := range m {
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package iter
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sort"
+
+	"golang.org/x/exp/constraints"
+)
+
+// SortBy の設定。
+type SortOptions struct {
+	// メモリ上でソートする値の最大数。超えた分はソートして一時ファイルに書き出す。
+	MaxInMemory int
+	// 一時ファイルを作成するディレクトリ。空の場合は os.TempDir() を使う。
+	TempDir string
+	// 一時ファイルの符号化方式。
+	Codec Codec
+}
+
+func NewSortOptions() *SortOptions {
+	return &SortOptions{
+		MaxInMemory: 1 << 16,
+		TempDir:     "",
+		Codec:       GobCodec,
+	}
+}
+
+func (opts *SortOptions) WithMaxInMemory(maxInMemory int) *SortOptions {
+	opts.MaxInMemory = maxInMemory
+	return opts
+}
+
+func (opts *SortOptions) WithTempDir(tempDir string) *SortOptions {
+	opts.TempDir = tempDir
+	return opts
+}
+
+func (opts *SortOptions) WithCodec(codec Codec) *SortOptions {
+	opts.Codec = codec
+	return opts
+}
+
+// 値をソートしたイテレータを返す。ソートは安定で、最初に値を読んだときに行われる。
+// 値の数が opts.MaxInMemory を超えた場合は、一時ファイルを使って外部ソートする。
+// 一時ファイルはイテレータが終了したとき、またはエラーが起きたときに削除される。
+// opts が nil の場合は NewSortOptions() を使う。
+func SortBy[V any](iter Iter[V], less func(V, V) (bool, error), opts *SortOptions) Iter[V] {
+	if opts == nil {
+		opts = NewSortOptions()
+	}
+	var sorted Iter[V]
+	var files []*os.File
+	cleanup := func() error {
+		var err error
+		for _, f := range files {
+			if err1 := f.Close(); err == nil {
+				err = err1
+			}
+			if err1 := os.Remove(f.Name()); err == nil {
+				err = err1
+			}
+		}
+		files = nil
+		return err
+	}
+	return FromFuncWithClose(func(ctx Context) (V, bool) {
+		if sorted == nil {
+			var err error
+			sorted, err = externalSort(iter, less, opts, &files)
+			if err != nil {
+				ctx.SetErr(err)
+				return *new(V), false
+			}
+		}
+		v, ok := sorted.Next()
+		if !ok {
+			ctx.SetErr(sorted.Err())
+		}
+		return v, ok
+	}, func() error {
+		err := closer(iter, sorted)()
+		if err1 := cleanup(); err == nil {
+			err = err1
+		}
+		return err
+	})
+}
+
+// 値をソートしたイテレータを返す。
+// opts が nil の場合は NewSortOptions() を使う。
+func Sort[V constraints.Ordered](iter Iter[V], opts *SortOptions) Iter[V] {
+	return SortBy(iter, func(v1 V, v2 V) (bool, error) { return v1 < v2, nil }, opts)
+}
+
+func externalSort[V any](iter Iter[V], less func(V, V) (bool, error), opts *SortOptions, files *[]*os.File) (Iter[V], error) {
+	defer Close(iter)
+
+	limit := opts.MaxInMemory
+	if limit <= 0 {
+		limit = NewSortOptions().MaxInMemory
+	}
+	codec := opts.Codec
+	if codec == nil {
+		codec = GobCodec
+	}
+
+	buf := []V{}
+	for {
+		v, ok := iter.Next()
+		if ok {
+			buf = append(buf, v)
+			if len(buf) < limit {
+				continue
+			}
+		} else if err := iter.Err(); err != nil {
+			return nil, err
+		}
+
+		if err := sortSlice(buf, less); err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+
+		f, err := os.CreateTemp(opts.TempDir, "gu-sort-*")
+		if err != nil {
+			return nil, err
+		}
+		*files = append(*files, f)
+		if err := writeRun(f, codec, buf); err != nil {
+			return nil, err
+		}
+		for i := range buf {
+			buf[i] = *new(V)
+		}
+		buf = buf[:0]
+	}
+
+	if len(*files) == 0 {
+		return FromSlice(buf), nil
+	}
+
+	runs := make([]Iter[V], 0, len(*files)+1)
+	for _, f := range *files {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		runs = append(runs, fromDecoder[V](codec.NewDecoder(bufio.NewReader(f))))
+	}
+	runs = append(runs, FromSlice(buf))
+	return mergeSorted(less, runs), nil
+}
+
+func writeRun[V any](w io.Writer, codec Codec, slice []V) error {
+	bw := bufio.NewWriter(w)
+	encoder := codec.NewEncoder(bw)
+	for _, v := range slice {
+		if err := encoder.Encode(v); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+func sortSlice[V any](slice []V, less func(V, V) (bool, error)) error {
+	var err error
+	sort.SliceStable(slice, func(i, j int) bool {
+		if err != nil {
+			return false
+		}
+		ok, err1 := less(slice[i], slice[j])
+		if err1 != nil {
+			err = err1
+			return false
+		}
+		return ok
+	})
+	return err
+}
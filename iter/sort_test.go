@@ -0,0 +1,119 @@
+package iter
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+type sortItem struct {
+	Key   int
+	Index int
+}
+
+func sortItems(n int) []sortItem {
+	r := rand.New(rand.NewSource(1))
+	items := make([]sortItem, n)
+	for i := range items {
+		items[i] = sortItem{Key: r.Intn(50), Index: i}
+	}
+	return items
+}
+
+func lessKey(v1 sortItem, v2 sortItem) (bool, error) {
+	return v1.Key < v2.Key, nil
+}
+
+func TestSortBySpill(t *testing.T) {
+	for _, codec := range []Codec{GobCodec, JSONCodec} {
+		dir := t.TempDir()
+		iter := SortBy(FromSlice(sortItems(1000)), lessKey, NewSortOptions().WithMaxInMemory(10).WithTempDir(dir).WithCodec(codec))
+
+		first, ok := iter.Next()
+		if !ok {
+			t.Fatal(iter.Err())
+		}
+		if n := tempFiles(t, dir); n != 100 {
+			t.Fatalf("%d temp files while reading, want 100", n)
+		}
+		rest, err := ToSlice(iter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := append([]sortItem{first}, rest...)
+		if len(got) != 1000 {
+			t.Fatalf("got %d values, want 1000", len(got))
+		}
+		for i := 1; i < len(got); i++ {
+			prev, v := got[i-1], got[i]
+			if prev.Key > v.Key || (prev.Key == v.Key && prev.Index > v.Index) {
+				t.Fatalf("got[%d] = %v after %v, not stably sorted", i, v, prev)
+			}
+		}
+		if n := tempFiles(t, dir); n != 0 {
+			t.Fatalf("%d temp files after reading, want 0", n)
+		}
+	}
+}
+
+func TestSortByInMemory(t *testing.T) {
+	dir := t.TempDir()
+	got, err := ToSlice(Sort(FromSlice([]int{3, 1, 2}), NewSortOptions().WithTempDir(dir)))
+	if err != nil || len(got) != 3 || got[0] != 1 || got[1] != 2 || got[2] != 3 {
+		t.Fatalf("got %v, %v", got, err)
+	}
+	if n := tempFiles(t, dir); n != 0 {
+		t.Fatalf("%d temp files, want 0", n)
+	}
+}
+
+func TestSortByCloseRemovesTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	iter := SortBy(FromSlice(sortItems(100)), lessKey, NewSortOptions().WithMaxInMemory(10).WithTempDir(dir))
+	for i := 0; i < 5; i++ {
+		if _, ok := iter.Next(); !ok {
+			t.Fatal(iter.Err())
+		}
+	}
+	if n := tempFiles(t, dir); n == 0 {
+		t.Fatal("no temp files while reading")
+	}
+	if err := Close(iter); err != nil {
+		t.Fatal(err)
+	}
+	if n := tempFiles(t, dir); n != 0 {
+		t.Fatalf("%d temp files after Close, want 0", n)
+	}
+}
+
+func TestSortByErrorRemovesTempFiles(t *testing.T) {
+	t.Run("source", func(t *testing.T) {
+		dir := t.TempDir()
+		_, err := ToSlice(Sort(failAfter(50), NewSortOptions().WithMaxInMemory(10).WithTempDir(dir)))
+		if !errors.Is(err, errTest) {
+			t.Fatalf("err = %v, want %v", err, errTest)
+		}
+		if n := tempFiles(t, dir); n != 0 {
+			t.Fatalf("%d temp files after error, want 0", n)
+		}
+	})
+
+	t.Run("less", func(t *testing.T) {
+		dir := t.TempDir()
+		calls := 0
+		less := func(v1 sortItem, v2 sortItem) (bool, error) {
+			calls++
+			if calls > 200 {
+				return false, errTest
+			}
+			return lessKey(v1, v2)
+		}
+		_, err := ToSlice(SortBy(FromSlice(sortItems(100)), less, NewSortOptions().WithMaxInMemory(10).WithTempDir(dir)))
+		if !errors.Is(err, errTest) {
+			t.Fatalf("err = %v, want %v", err, errTest)
+		}
+		if n := tempFiles(t, dir); n != 0 {
+			t.Fatalf("%d temp files after error, want 0", n)
+		}
+	})
+}
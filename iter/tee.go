@@ -0,0 +1,285 @@
+package iter
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/thamaji/gu/tuple"
+)
+
+// Tee で遅れたイテレータが読むべき値がバッファから捨てられたときのエラー。
+var ErrTeeOverflow = errors.New("iter: tee buffer overflow")
+
+// Tee の設定。
+type TeeOptions struct {
+	// 共有バッファに保持する値の最大数。
+	BufferSize int
+	// true の場合、バッファが一杯になると先行するイテレータは遅れたイテレータを待つ。
+	// false の場合、先行するイテレータは古い値を捨てて進み、遅れたイテレータは ErrTeeOverflow で停止する。
+	Block bool
+}
+
+func NewTeeOptions() *TeeOptions {
+	return &TeeOptions{
+		BufferSize: 1024,
+		Block:      true,
+	}
+}
+
+func (opts *TeeOptions) WithBufferSize(bufferSize int) *TeeOptions {
+	opts.BufferSize = bufferSize
+	return opts
+}
+
+func (opts *TeeOptions) WithBlock(block bool) *TeeOptions {
+	opts.Block = block
+	return opts
+}
+
+// ひとつのイテレータを共有するn個のイテレータを返す。
+// 値は共有バッファを通してそれぞれのイテレータに渡される。
+// Block が true の場合、それぞれのイテレータは別のゴルーチンで読むこと。
+// 元のイテレータは、すべてのイテレータが終了するか閉じられたときに閉じられる。
+// opts が nil の場合は NewTeeOptions() を使う。
+func Tee[V any](iter Iter[V], n int, opts *TeeOptions) []Iter[V] {
+	if opts == nil {
+		opts = NewTeeOptions()
+	}
+	size := opts.BufferSize
+	if size < 1 {
+		size = 1
+	}
+	s := &teeShared[V]{
+		iter:   iter,
+		pos:    make([]int, n),
+		active: n,
+		size:   size,
+		block:  opts.Block,
+	}
+	s.cond = sync.NewCond(&s.mu)
+
+	iters := make([]Iter[V], n)
+	for i := range iters {
+		iters[i] = &teeIter[V]{shared: s, index: i}
+	}
+	return iters
+}
+
+// 条件を満たすイテレータと満たさないイテレータを返す。
+// PartitionBy と異なり、値は読まれたときに判定される。
+// opts が nil の場合は Block を false にした NewTeeOptions() を使うので、片方を読み切ってからもう片方を読むと、BufferSize を超えた分は ErrTeeOverflow になる。
+// Block が true の場合は、それぞれのイテレータを別のゴルーチンで読むこと。
+func LazyPartitionBy[V any](iter Iter[V], f func(V) (bool, error), opts *TeeOptions) (Iter[V], Iter[V]) {
+	iters := Tee(Map(iter, func(v V) (tuple.T2[V, bool], error) {
+		ok, err := f(v)
+		return tuple.NewT2(v, ok), err
+	}), 2, lazyTeeOptions(opts))
+	iter1 := Collect(iters[0], func(t tuple.T2[V, bool]) (V, bool, error) { return t.V1, t.V2, nil })
+	iter2 := Collect(iters[1], func(t tuple.T2[V, bool]) (V, bool, error) { return t.V1, !t.V2, nil })
+	return iter1, iter2
+}
+
+// 値の一致するイテレータと一致しないイテレータを返す。
+// Partition と異なり、値は読まれたときに判定される。
+// opts が nil の場合は Block を false にした NewTeeOptions() を使うので、片方を読み切ってからもう片方を読むと、BufferSize を超えた分は ErrTeeOverflow になる。
+// Block が true の場合は、それぞれのイテレータを別のゴルーチンで読むこと。
+func LazyPartition[V comparable](iter Iter[V], v V, opts *TeeOptions) (Iter[V], Iter[V]) {
+	return LazyPartitionBy(iter, func(v1 V) (bool, error) { return v1 == v, nil }, opts)
+}
+
+// 値のペアを分離して２つのイテレータを返す。
+// Unzip2 と異なり、値は読まれたときに分離される。
+// opts が nil の場合は Block を false にした NewTeeOptions() を使うので、片方を読み切ってからもう片方を読むと、BufferSize を超えた分は ErrTeeOverflow になる。
+// Block が true の場合は、それぞれのイテレータを別のゴルーチンで読むこと。
+func LazyUnzip2[V1 any, V2 any](iter Iter[tuple.T2[V1, V2]], opts *TeeOptions) (Iter[V1], Iter[V2]) {
+	iters := Tee(iter, 2, lazyTeeOptions(opts))
+	return Map(iters[0], func(t tuple.T2[V1, V2]) (V1, error) { return t.V1, nil }),
+		Map(iters[1], func(t tuple.T2[V1, V2]) (V2, error) { return t.V2, nil })
+}
+
+// 値のペアを分離して３つのイテレータを返す。
+// Unzip3 と異なり、値は読まれたときに分離される。
+// opts が nil の場合は Block を false にした NewTeeOptions() を使うので、片方を読み切ってからもう片方を読むと、BufferSize を超えた分は ErrTeeOverflow になる。
+// Block が true の場合は、それぞれのイテレータを別のゴルーチンで読むこと。
+func LazyUnzip3[V1 any, V2 any, V3 any](iter Iter[tuple.T3[V1, V2, V3]], opts *TeeOptions) (Iter[V1], Iter[V2], Iter[V3]) {
+	iters := Tee(iter, 3, lazyTeeOptions(opts))
+	return Map(iters[0], func(t tuple.T3[V1, V2, V3]) (V1, error) { return t.V1, nil }),
+		Map(iters[1], func(t tuple.T3[V1, V2, V3]) (V2, error) { return t.V2, nil }),
+		Map(iters[2], func(t tuple.T3[V1, V2, V3]) (V3, error) { return t.V3, nil })
+}
+
+// 値のペアを分離して４つのイテレータを返す。
+// Unzip4 と異なり、値は読まれたときに分離される。
+// opts が nil の場合は Block を false にした NewTeeOptions() を使うので、片方を読み切ってからもう片方を読むと、BufferSize を超えた分は ErrTeeOverflow になる。
+// Block が true の場合は、それぞれのイテレータを別のゴルーチンで読むこと。
+func LazyUnzip4[V1 any, V2 any, V3 any, V4 any](iter Iter[tuple.T4[V1, V2, V3, V4]], opts *TeeOptions) (Iter[V1], Iter[V2], Iter[V3], Iter[V4]) {
+	iters := Tee(iter, 4, lazyTeeOptions(opts))
+	return Map(iters[0], func(t tuple.T4[V1, V2, V3, V4]) (V1, error) { return t.V1, nil }),
+		Map(iters[1], func(t tuple.T4[V1, V2, V3, V4]) (V2, error) { return t.V2, nil }),
+		Map(iters[2], func(t tuple.T4[V1, V2, V3, V4]) (V3, error) { return t.V3, nil }),
+		Map(iters[3], func(t tuple.T4[V1, V2, V3, V4]) (V4, error) { return t.V4, nil })
+}
+
+// 値のペアを分離して５つのイテレータを返す。
+// Unzip5 と異なり、値は読まれたときに分離される。
+// opts が nil の場合は Block を false にした NewTeeOptions() を使うので、片方を読み切ってからもう片方を読むと、BufferSize を超えた分は ErrTeeOverflow になる。
+// Block が true の場合は、それぞれのイテレータを別のゴルーチンで読むこと。
+func LazyUnzip5[V1 any, V2 any, V3 any, V4 any, V5 any](iter Iter[tuple.T5[V1, V2, V3, V4, V5]], opts *TeeOptions) (Iter[V1], Iter[V2], Iter[V3], Iter[V4], Iter[V5]) {
+	iters := Tee(iter, 5, lazyTeeOptions(opts))
+	return Map(iters[0], func(t tuple.T5[V1, V2, V3, V4, V5]) (V1, error) { return t.V1, nil }),
+		Map(iters[1], func(t tuple.T5[V1, V2, V3, V4, V5]) (V2, error) { return t.V2, nil }),
+		Map(iters[2], func(t tuple.T5[V1, V2, V3, V4, V5]) (V3, error) { return t.V3, nil }),
+		Map(iters[3], func(t tuple.T5[V1, V2, V3, V4, V5]) (V4, error) { return t.V4, nil }),
+		Map(iters[4], func(t tuple.T5[V1, V2, V3, V4, V5]) (V5, error) { return t.V5, nil })
+}
+
+// 値のペアを分離して６つのイテレータを返す。
+// Unzip6 と異なり、値は読まれたときに分離される。
+// opts が nil の場合は Block を false にした NewTeeOptions() を使うので、片方を読み切ってからもう片方を読むと、BufferSize を超えた分は ErrTeeOverflow になる。
+// Block が true の場合は、それぞれのイテレータを別のゴルーチンで読むこと。
+func LazyUnzip6[V1 any, V2 any, V3 any, V4 any, V5 any, V6 any](iter Iter[tuple.T6[V1, V2, V3, V4, V5, V6]], opts *TeeOptions) (Iter[V1], Iter[V2], Iter[V3], Iter[V4], Iter[V5], Iter[V6]) {
+	iters := Tee(iter, 6, lazyTeeOptions(opts))
+	return Map(iters[0], func(t tuple.T6[V1, V2, V3, V4, V5, V6]) (V1, error) { return t.V1, nil }),
+		Map(iters[1], func(t tuple.T6[V1, V2, V3, V4, V5, V6]) (V2, error) { return t.V2, nil }),
+		Map(iters[2], func(t tuple.T6[V1, V2, V3, V4, V5, V6]) (V3, error) { return t.V3, nil }),
+		Map(iters[3], func(t tuple.T6[V1, V2, V3, V4, V5, V6]) (V4, error) { return t.V4, nil }),
+		Map(iters[4], func(t tuple.T6[V1, V2, V3, V4, V5, V6]) (V5, error) { return t.V5, nil }),
+		Map(iters[5], func(t tuple.T6[V1, V2, V3, V4, V5, V6]) (V6, error) { return t.V6, nil })
+}
+
+// Lazy* の関数で使う設定を返す。
+// 順に読まれても止まらないように、nil の場合は Block を false にする。
+func lazyTeeOptions(opts *TeeOptions) *TeeOptions {
+	if opts == nil {
+		return NewTeeOptions().WithBlock(false)
+	}
+	return opts
+}
+
+type teeShared[V any] struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	iter    Iter[V]
+	buf     []V   // base 番目からの値
+	base    int   // buf[0] の位置
+	pos     []int // それぞれのイテレータが次に読む位置。閉じられたら -1
+	active  int
+	size    int
+	block   bool
+	reading bool
+	done    bool
+	err     error
+}
+
+// すべてのイテレータが読み終えた値をバッファから捨てる。
+func (s *teeShared[V]) trim() {
+	low := s.base + len(s.buf)
+	for _, p := range s.pos {
+		if p >= 0 && p < low {
+			low = p
+		}
+	}
+	if low <= s.base {
+		return
+	}
+	n := low - s.base
+	for i := 0; i < n; i++ {
+		s.buf[i] = *new(V)
+	}
+	s.buf = s.buf[n:]
+	s.base = low
+}
+
+func (s *teeShared[V]) next(i int) (V, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		p := s.pos[i]
+		if p < 0 {
+			return *new(V), false, nil
+		}
+		if p < s.base {
+			return *new(V), false, ErrTeeOverflow
+		}
+		if p < s.base+len(s.buf) {
+			v := s.buf[p-s.base]
+			s.pos[i]++
+			s.trim()
+			s.cond.Broadcast()
+			return v, true, nil
+		}
+		if s.done {
+			return *new(V), false, s.err
+		}
+		if s.reading || (s.block && len(s.buf) >= s.size) {
+			s.cond.Wait()
+			continue
+		}
+
+		s.reading = true
+		s.mu.Unlock()
+		v, ok := s.iter.Next()
+		s.mu.Lock()
+		s.reading = false
+		if ok {
+			s.buf = append(s.buf, v)
+			if len(s.buf) > s.size {
+				s.buf[0] = *new(V)
+				s.buf = s.buf[1:]
+				s.base++
+			}
+		} else {
+			s.done = true
+			s.err = s.iter.Err()
+		}
+		s.cond.Broadcast()
+	}
+}
+
+func (s *teeShared[V]) close(i int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pos[i] < 0 {
+		return nil
+	}
+	s.pos[i] = -1
+	s.active--
+	s.trim()
+	s.cond.Broadcast()
+	if s.active > 0 {
+		return nil
+	}
+	for s.reading {
+		s.cond.Wait()
+	}
+	return Close(s.iter)
+}
+
+type teeIter[V any] struct {
+	shared *teeShared[V]
+	index  int
+	err    error
+}
+
+func (iter *teeIter[V]) Next() (V, bool) {
+	if iter.err != nil {
+		return *new(V), false
+	}
+	v, ok, err := iter.shared.next(iter.index)
+	if !ok {
+		iter.err = err
+		// 終端に達したら資源を解放する。
+		if err := iter.Close(); err != nil && iter.err == nil {
+			iter.err = err
+		}
+	}
+	return v, ok
+}
+
+func (iter *teeIter[V]) Err() error {
+	return iter.err
+}
+
+func (iter *teeIter[V]) Close() error {
+	return iter.shared.close(iter.index)
+}
@@ -0,0 +1,84 @@
+package iter
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/thamaji/gu/tuple"
+)
+
+func TestTeeConcurrent(t *testing.T) {
+	iters := Tee(Range(0, 5000, 1), 3, NewTeeOptions().WithBufferSize(16))
+	results := make([][]int, len(iters))
+	errs := make([]error, len(iters))
+	var wg sync.WaitGroup
+	for i := range iters {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = ToSlice(iters[i])
+		}(i)
+	}
+	wg.Wait()
+	for i := range iters {
+		if errs[i] != nil {
+			t.Fatalf("iter %d: %v", i, errs[i])
+		}
+		if len(results[i]) != 5000 || results[i][4999] != 4999 {
+			t.Fatalf("iter %d: got %d values", i, len(results[i]))
+		}
+	}
+}
+
+func TestTeeOverflow(t *testing.T) {
+	iters := Tee(Range(0, 100, 1), 2, NewTeeOptions().WithBufferSize(10).WithBlock(false))
+	if s, err := ToSlice(iters[0]); err != nil || len(s) != 100 {
+		t.Fatalf("got %d values, %v", len(s), err)
+	}
+	if _, err := ToSlice(iters[1]); !errors.Is(err, ErrTeeOverflow) {
+		t.Fatalf("err = %v, want %v", err, ErrTeeOverflow)
+	}
+}
+
+func TestLazyUnzip2Sequential(t *testing.T) {
+	pairs := func(n int) Iter[tuple.T2[int, int]] {
+		return Map(Range(0, n, 1), func(v int) (tuple.T2[int, int], error) { return tuple.NewT2(v, -v), nil })
+	}
+
+	a, b := LazyUnzip2(pairs(100), nil)
+	if s, err := ToSlice(a); err != nil || len(s) != 100 {
+		t.Fatalf("a: got %d values, %v", len(s), err)
+	}
+	if s, err := ToSlice(b); err != nil || len(s) != 100 || s[99] != -99 {
+		t.Fatalf("b: got %v, %v", s, err)
+	}
+
+	// バッファを超える場合は止まらずにエラーになる。
+	a, b = LazyUnzip2(pairs(3000), nil)
+	if s, err := ToSlice(a); err != nil || len(s) != 3000 {
+		t.Fatalf("a: got %d values, %v", len(s), err)
+	}
+	if _, err := ToSlice(b); !errors.Is(err, ErrTeeOverflow) {
+		t.Fatalf("b: err = %v, want %v", err, ErrTeeOverflow)
+	}
+}
+
+func TestLazyPartitionByConcurrent(t *testing.T) {
+	even, odd := LazyPartitionBy(Range(0, 3000, 1), func(v int) (bool, error) { return v%2 == 0, nil }, NewTeeOptions())
+	var evens []int
+	var err error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		evens, err = ToSlice(even)
+	}()
+	odds, err1 := ToSlice(odd)
+	<-done
+	if err != nil || err1 != nil {
+		t.Fatal(err, err1)
+	}
+	if len(evens) != 1500 || len(odds) != 1500 {
+		t.Fatalf("got %d evens and %d odds", len(evens), len(odds))
+	}
+}
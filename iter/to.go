@@ -1,14 +1,18 @@
 package iter
 
 import (
+	"bytes"
 	"encoding/json"
+	"io"
 
 	"github.com/thamaji/gu/must"
 	"github.com/thamaji/gu/tuple"
 )
 
 // イテレータからスライスをつくる。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func ToSlice[V any](iter Iter[V]) ([]V, error) {
+	defer Close(iter)
 	slice := []V{}
 	for {
 		v, ok := iter.Next()
@@ -29,7 +33,9 @@ func MustToSlice[V any](iter Iter[V]) []V {
 }
 
 // イテレータからマップをつくる。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func ToMap[K comparable, V any](iter Iter[tuple.T2[K, V]]) (map[K]V, error) {
+	defer Close(iter)
 	m := map[K]V{}
 	for {
 		v, ok := iter.Next()
@@ -51,7 +57,9 @@ func MustToMap[K comparable, V any](iter Iter[tuple.T2[K, V]]) map[K]V {
 
 // イテレータからポインタをつくる。
 // ふたつ目以降の値は無視される。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func ToPtr[V any](iter Iter[V]) (*V, error) {
+	defer Close(iter)
 	for {
 		v, ok := iter.Next()
 		if !ok {
@@ -68,7 +76,10 @@ func MustToPtr[V any](iter Iter[V]) *V {
 }
 
 // イテレータを json.Encoder に書き込む。
+// 値ごとに改行で区切られた JSON Lines になる。
+// iter は閉じられるので、読み残した値はあとから読めない。
 func WriteJSON[V any](iter Iter[V], encoder *json.Encoder) error {
+	defer Close(iter)
 	for {
 		v, ok := iter.Next()
 		if !ok {
@@ -83,3 +94,85 @@ func WriteJSON[V any](iter Iter[V], encoder *json.Encoder) error {
 	}
 	return nil
 }
+
+// WriteJSONArray の設定。json.Encoder の SetIndent と SetEscapeHTML に対応する。
+type JSONArrayOptions struct {
+	// 各行の先頭につける文字列。
+	Prefix string
+	// インデントに使う文字列。Prefix と Indent がどちらも空の場合は改行しない。
+	Indent string
+	// HTML で問題になる文字をエスケープするかどうか。
+	EscapeHTML bool
+}
+
+func NewJSONArrayOptions() *JSONArrayOptions {
+	return &JSONArrayOptions{
+		Prefix:     "",
+		Indent:     "",
+		EscapeHTML: true,
+	}
+}
+
+func (opts *JSONArrayOptions) WithIndent(prefix string, indent string) *JSONArrayOptions {
+	opts.Prefix = prefix
+	opts.Indent = indent
+	return opts
+}
+
+func (opts *JSONArrayOptions) WithEscapeHTML(escapeHTML bool) *JSONArrayOptions {
+	opts.EscapeHTML = escapeHTML
+	return opts
+}
+
+// イテレータをひとつの JSON の配列として書き込む。
+// 出力は json.Encoder で配列を書き込んだときと同じになり、最後に改行が入る。
+// opts が nil の場合は NewJSONArrayOptions() を使う。
+// iter は閉じられるので、読み残した値はあとから読めない。
+func WriteJSONArray[V any](iter Iter[V], w io.Writer, opts *JSONArrayOptions) error {
+	defer Close(iter)
+	if opts == nil {
+		opts = NewJSONArrayOptions()
+	}
+	indent := opts.Prefix != "" || opts.Indent != ""
+
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(opts.EscapeHTML)
+	if indent {
+		encoder.SetIndent(opts.Prefix+opts.Indent, opts.Indent)
+	}
+
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	i := 0
+	for ; ; i++ {
+		v, ok := iter.Next()
+		if !ok {
+			if err := iter.Err(); err != nil {
+				return err
+			}
+			break
+		}
+		buf.Reset()
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if indent {
+			buf.WriteString("\n" + opts.Prefix + opts.Indent)
+		}
+		if err := encoder.Encode(v); err != nil {
+			return err
+		}
+		// Encode が最後に加える改行を除く。
+		if _, err := w.Write(buf.Bytes()[:buf.Len()-1]); err != nil {
+			return err
+		}
+	}
+	end := "]\n"
+	if indent && i > 0 {
+		end = "\n" + opts.Prefix + end
+	}
+	_, err := io.WriteString(w, end)
+	return err
+}
@@ -0,0 +1,99 @@
+package iter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type jsonItem struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+var jsonItems = []jsonItem{
+	{Name: "a", Tags: []string{"x", "y"}},
+	{Name: "<b>", Tags: nil},
+	{Name: "c", Tags: []string{}},
+}
+
+// WriteJSONArray の出力が、スライスを json.Encoder で書き込んだものと同じになることを確かめる。
+func TestWriteJSONArray(t *testing.T) {
+	cases := map[string]struct {
+		opts  *JSONArrayOptions
+		setup func(*json.Encoder)
+	}{
+		"nil":      {nil, func(*json.Encoder) {}},
+		"indent":   {NewJSONArrayOptions().WithIndent("", "  "), func(e *json.Encoder) { e.SetIndent("", "  ") }},
+		"prefix":   {NewJSONArrayOptions().WithIndent("> ", "\t"), func(e *json.Encoder) { e.SetIndent("> ", "\t") }},
+		"noescape": {NewJSONArrayOptions().WithEscapeHTML(false), func(e *json.Encoder) { e.SetEscapeHTML(false) }},
+	}
+	for name, c := range cases {
+		for _, items := range [][]jsonItem{jsonItems, {}} {
+			want := &bytes.Buffer{}
+			encoder := json.NewEncoder(want)
+			c.setup(encoder)
+			if err := encoder.Encode(items); err != nil {
+				t.Fatal(err)
+			}
+
+			got := &bytes.Buffer{}
+			if err := WriteJSONArray(FromSlice(items), got, c.opts); err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != want.String() {
+				t.Errorf("%s: got %q, want %q", name, got.String(), want.String())
+			}
+		}
+	}
+}
+
+func TestWriteJSONArrayError(t *testing.T) {
+	err := WriteJSONArray(failAfter(2), &bytes.Buffer{}, nil)
+	if !errors.Is(err, errTest) {
+		t.Fatalf("err = %v, want %v", err, errTest)
+	}
+}
+
+func checkJSONItems(t *testing.T, got []jsonItem) {
+	t.Helper()
+	if len(got) != len(jsonItems) {
+		t.Fatalf("got %v, want %v", got, jsonItems)
+	}
+	for i := range got {
+		if got[i].Name != jsonItems[i].Name || len(got[i].Tags) != len(jsonItems[i].Tags) {
+			t.Fatalf("got %v, want %v", got, jsonItems)
+		}
+	}
+}
+
+func TestJSONArrayRoundTrip(t *testing.T) {
+	for _, opts := range []*JSONArrayOptions{nil, NewJSONArrayOptions().WithIndent("", "  ")} {
+		buf := &bytes.Buffer{}
+		if err := WriteJSONArray(FromSlice(jsonItems), buf, opts); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := ToSlice(FromJSONArray[jsonItem](json.NewDecoder(bytes.NewReader(buf.Bytes()))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkJSONItems(t, got)
+
+		nested := `{"meta":{"count":3},"data":{"items":` + buf.String() + `}}`
+		got, err = ToSlice(FromJSONArrayAt[jsonItem](json.NewDecoder(strings.NewReader(nested)), ".data.items"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkJSONItems(t, got)
+	}
+}
+
+func TestFromJSONArrayAtNotFound(t *testing.T) {
+	_, err := ToSlice(FromJSONArrayAt[jsonItem](json.NewDecoder(strings.NewReader(`{"data":{}}`)), ".data.items"))
+	if err == nil {
+		t.Fatal("err = nil, want an error for a missing path")
+	}
+}
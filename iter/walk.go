@@ -0,0 +1,151 @@
+package iter
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// WalkFS が返すエントリ。
+type WalkEntry struct {
+	fs.DirEntry
+	// root を含むパス。
+	Path string
+	// root からの深さ。root の直下は 1。
+	Depth int
+}
+
+// WalkFS の設定。
+type WalkOptions struct {
+	// 空でない場合、いずれかのパターンに一致するエントリだけを返す。ディレクトリは一致しなくてもたどる。
+	Include []string
+	// いずれかのパターンに一致するエントリは返さない。ディレクトリの場合は中もたどらない。
+	Exclude []string
+	// たどる深さの最大値。0 の場合は制限しない。
+	MaxDepth int
+}
+
+func NewWalkOptions() *WalkOptions {
+	return &WalkOptions{
+		Include:  nil,
+		Exclude:  nil,
+		MaxDepth: 0,
+	}
+}
+
+func (opts *WalkOptions) WithInclude(patterns ...string) *WalkOptions {
+	opts.Include = patterns
+	return opts
+}
+
+func (opts *WalkOptions) WithExclude(patterns ...string) *WalkOptions {
+	opts.Exclude = patterns
+	return opts
+}
+
+func (opts *WalkOptions) WithMaxDepth(maxDepth int) *WalkOptions {
+	opts.MaxDepth = maxDepth
+	return opts
+}
+
+// fs.FS のディレクトリを再帰的にたどるイテレータをつくる。root 自身は含まない。
+// エントリは fs.WalkDir と同じく辞書順の深さ優先で返され、ディレクトリは値を読み進めたときに一つずつ読まれる。
+// パターンは path.Match の形式で、/ を含む場合は root からの相対パスと、含まない場合はエントリの名前と比較する。
+// opts が nil の場合は NewWalkOptions() を使う。
+func WalkFS(fsys fs.FS, root string, opts *WalkOptions) *WalkIter {
+	if opts == nil {
+		opts = NewWalkOptions()
+	}
+	iter := &WalkIter{fsys: fsys, root: root, opts: opts}
+	for _, pattern := range append(append([]string{}, opts.Include...), opts.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			iter.err = err
+			return iter
+		}
+	}
+	iter.pending = &walkDir{path: root, depth: 0}
+	return iter
+}
+
+// WalkFS のイテレータ。
+type WalkIter struct {
+	fsys    fs.FS
+	root    string
+	opts    *WalkOptions
+	stack   []*walkDir
+	pending *walkDir // 次に読むディレクトリ
+	err     error
+}
+
+type walkDir struct {
+	path    string
+	depth   int
+	entries []fs.DirEntry
+}
+
+func (iter *WalkIter) Next() (WalkEntry, bool) {
+	for iter.err == nil {
+		if iter.pending != nil {
+			dir := iter.pending
+			iter.pending = nil
+			entries, err := fs.ReadDir(iter.fsys, dir.path)
+			if err != nil {
+				iter.err = err
+				break
+			}
+			dir.entries = entries
+			iter.stack = append(iter.stack, dir)
+		}
+
+		if len(iter.stack) == 0 {
+			break
+		}
+		dir := iter.stack[len(iter.stack)-1]
+		if len(dir.entries) == 0 {
+			iter.stack[len(iter.stack)-1] = nil
+			iter.stack = iter.stack[:len(iter.stack)-1]
+			continue
+		}
+		entry := dir.entries[0]
+		dir.entries[0] = nil
+		dir.entries = dir.entries[1:]
+
+		e := WalkEntry{DirEntry: entry, Path: path.Join(dir.path, entry.Name()), Depth: dir.depth + 1}
+		if iter.match(iter.opts.Exclude, e) {
+			continue
+		}
+		if e.IsDir() && (iter.opts.MaxDepth <= 0 || e.Depth < iter.opts.MaxDepth) {
+			iter.pending = &walkDir{path: e.Path, depth: e.Depth}
+		}
+		if len(iter.opts.Include) > 0 && !iter.match(iter.opts.Include, e) {
+			continue
+		}
+		return e, true
+	}
+	return WalkEntry{}, false
+}
+
+func (iter *WalkIter) Err() error {
+	return iter.err
+}
+
+// 直前に返したディレクトリの中をたどらないようにする。
+func (iter *WalkIter) SkipDir() {
+	iter.pending = nil
+}
+
+func (iter *WalkIter) match(patterns []string, e WalkEntry) bool {
+	for _, pattern := range patterns {
+		name := e.Name()
+		if strings.Contains(pattern, "/") {
+			name = e.Path
+			if iter.root != "." {
+				name = strings.TrimPrefix(e.Path, iter.root+"/")
+			}
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
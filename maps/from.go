@@ -15,10 +15,11 @@ func From[K comparable, V any](tuples ...tuple.T2[K, V]) map[K]V {
 }
 
 // キーと値のペアのイテレータからマップをつくる。
-func FromIter[K comparable, V any](iter iter.Iter[tuple.T2[K, V]]) map[K]V {
+func FromIter[K comparable, V any](it iter.Iter[tuple.T2[K, V]]) map[K]V {
+	defer iter.Close(it)
 	m := map[K]V{}
 	for {
-		v, ok := iter.Next()
+		v, ok := it.Next()
 		if !ok {
 			break
 		}
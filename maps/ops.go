@@ -121,8 +121,11 @@ func Clone[M ~map[K]V, K comparable, V any](m M) M {
 	return dst
 }
 
-// 値を１つランダムに返す。
+// 値を１つランダムに返す。マップが空の場合はゼロ値を返す。
 func Sample[K comparable, V any](m map[K]V, r *rand.Rand) V {
+	if len(m) == 0 {
+		return *new(V)
+	}
 	i, n := 0, r.Intn(len(m))
 	var k K
 	for k = range m {
@@ -421,6 +424,54 @@ func MustFold[K comparable, V1 any, V2 any](m map[K]V1, v V2, f func(V2, K, V1)
 	return must.Must1(Fold(m, v, f))
 }
 
+// 初期値と値を順に演算し、それぞれのキーまでの途中の結果をもつマップを返す。
+// 演算する順序は不定なので、結果も実行ごとに変わりうる。
+func Scan[K comparable, V1 any, V2 any](m map[K]V1, v V2, f func(V2, K, V1) (V2, error)) (map[K]V2, error) {
+	m2 := make(map[K]V2, len(m))
+	var err error
+	for k, v1 := range m {
+		v, err = f(v, k, v1)
+		if err != nil {
+			return nil, err
+		}
+		m2[k] = v
+	}
+	return m2, nil
+}
+
+// 初期値と値を順に演算し、それぞれのキーまでの途中の結果をもつマップを返す。実行中にエラーが起きた場合 panic する。
+func MustScan[K comparable, V1 any, V2 any](m map[K]V1, v V2, f func(V2, K, V1) (V2, error)) map[K]V2 {
+	return must.Must1(Scan(m, v, f))
+}
+
+// 状態を持ちながら値を変換したスライスを返す。
+// 関数は次の状態と、０個以上の変換した値を返す。演算する順序は不定。
+// 最後に flush が返す値が加えられる。flush は nil でもよい。
+func MapWithState[K comparable, V1 any, S any, V2 any](m map[K]V1, state S, f func(S, K, V1) (S, []V2, error), flush func(S) ([]V2, error)) ([]V2, error) {
+	dst := []V2{}
+	for k, v1 := range m {
+		next, values, err := f(state, k, v1)
+		if err != nil {
+			return nil, err
+		}
+		state = next
+		dst = append(dst, values...)
+	}
+	if flush != nil {
+		values, err := flush(state)
+		if err != nil {
+			return nil, err
+		}
+		dst = append(dst, values...)
+	}
+	return dst, nil
+}
+
+// 状態を持ちながら値を変換したスライスを返す。実行中にエラーが起きた場合 panic する。
+func MustMapWithState[K comparable, V1 any, S any, V2 any](m map[K]V1, state S, f func(S, K, V1) (S, []V2, error), flush func(S) ([]V2, error)) []V2 {
+	return must.Must1(MapWithState(m, state, f, flush))
+}
+
 // 条件を満たす値を返す。
 func FindBy[K comparable, V any](m map[K]V, f func(K, V) (bool, error)) (V, bool, error) {
 	for k, v := range m {
@@ -704,6 +755,51 @@ func Zip2[K comparable, V1 any, V2 any](m1 map[K]V1, m2 map[K]V2) map[K]tuple.T2
 	return dst
 }
 
+// ２つのマップの値をキーでペアにしたマップを返す（左外部結合）。
+// m2 にキーが無い場合、ペアの右側は nil になる。
+func LeftJoin[K comparable, V1 any, V2 any](m1 map[K]V1, m2 map[K]V2) map[K]tuple.T2[V1, *V2] {
+	dst := make(map[K]tuple.T2[V1, *V2], len(m1))
+	for k, v1 := range m1 {
+		if v2, ok := m2[k]; ok {
+			dst[k] = tuple.NewT2(v1, &v2)
+		} else {
+			dst[k] = tuple.NewT2[V1, *V2](v1, nil)
+		}
+	}
+	return dst
+}
+
+// ２つのマップの値をキーでペアにしたマップを返す（右外部結合）。
+// m1 にキーが無い場合、ペアの左側は nil になる。
+func RightJoin[K comparable, V1 any, V2 any](m1 map[K]V1, m2 map[K]V2) map[K]tuple.T2[*V1, V2] {
+	dst := make(map[K]tuple.T2[*V1, V2], len(m2))
+	for k, v2 := range m2 {
+		if v1, ok := m1[k]; ok {
+			dst[k] = tuple.NewT2(&v1, v2)
+		} else {
+			dst[k] = tuple.NewT2[*V1, V2](nil, v2)
+		}
+	}
+	return dst
+}
+
+// ２つのマップの値をキーでペアにしたマップを返す（完全外部結合）。
+// キーが無い側は nil になる。
+func FullOuterJoin[K comparable, V1 any, V2 any](m1 map[K]V1, m2 map[K]V2) map[K]tuple.T2[*V1, *V2] {
+	dst := make(map[K]tuple.T2[*V1, *V2], len(m1)+len(m2))
+	for k, v1 := range m1 {
+		v1 := v1
+		dst[k] = tuple.NewT2[*V1, *V2](&v1, nil)
+	}
+	for k, v2 := range m2 {
+		v2 := v2
+		t := dst[k]
+		t.V2 = &v2
+		dst[k] = t
+	}
+	return dst
+}
+
 // ３つのマップの同じキーの値をペアにしたマップを返す。
 func Zip3[K comparable, V1 any, V2 any, V3 any](m1 map[K]V1, m2 map[K]V2, m3 map[K]V3) map[K]tuple.T3[V1, V2, V3] {
 	n := len(m1)
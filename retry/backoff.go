@@ -1,6 +1,7 @@
 package retry
 
 import (
+	"context"
 	"math/rand"
 	"time"
 )
@@ -34,7 +35,7 @@ func (backoff *exponentialBackoff) WithMaxBackoff(maxBackoff time.Duration) *exp
 	return backoff
 }
 
-func (backoff exponentialBackoff) call(n int, f func() bool) bool {
+func (backoff exponentialBackoff) call(ctx context.Context, n int, f func() bool) bool {
 	if f() {
 		return false
 	}
@@ -53,7 +54,5 @@ func (backoff exponentialBackoff) call(n int, f func() bool) bool {
 		delay = backoff.MaxBackoff
 	}
 
-	time.Sleep(delay + time.Duration(rand.Int63n(backoff.Delay.Nanoseconds()))*time.Nanosecond)
-
-	return true
+	return wait(ctx, delay+time.Duration(rand.Int63n(backoff.Delay.Nanoseconds()))*time.Nanosecond)
 }
@@ -1,7 +1,14 @@
 package retry
 
+import (
+	"context"
+	"time"
+
+	"github.com/thamaji/gu/errs"
+)
+
 type Strategy interface {
-	call(int, func() bool) bool
+	call(context.Context, int, func() bool) bool
 }
 
 var defaultStrategy Strategy = Simple()
@@ -11,71 +18,201 @@ func SetDefaultStrategy(s Strategy) {
 }
 
 // リトライ戦略に従って、関数がエラーを返さなくなるまで再試行する
+// すべての試行が失敗した場合は、試行ごとのエラーを試行の回数とともにまとめた *errs.Errors を返す
 func Run(s Strategy, f func() error) (err error) {
+	return RunContext(context.Background(), s, f)
+}
+
+// リトライ戦略に従って、関数がエラーを返さなくなるまで再試行する
+// すべての試行が失敗した場合は、試行ごとのエラーを試行の回数とともにまとめた *errs.Errors を返す
+func Run1[V1 any](s Strategy, f func() (V1, error)) (v1 V1, err error) {
+	return Run1Context(context.Background(), s, f)
+}
+
+// リトライ戦略に従って、関数がエラーを返さなくなるまで再試行する
+// すべての試行が失敗した場合は、試行ごとのエラーを試行の回数とともにまとめた *errs.Errors を返す
+func Run2[V1 any, V2 any](s Strategy, f func() (V1, V2, error)) (v1 V1, v2 V2, err error) {
+	return Run2Context(context.Background(), s, f)
+}
+
+// リトライ戦略に従って、関数がエラーを返さなくなるまで再試行する
+// すべての試行が失敗した場合は、試行ごとのエラーを試行の回数とともにまとめた *errs.Errors を返す
+func Run3[V1 any, V2 any, V3 any](s Strategy, f func() (V1, V2, V3, error)) (v1 V1, v2 V2, v3 V3, err error) {
+	return Run3Context(context.Background(), s, f)
+}
+
+// リトライ戦略に従って、関数がエラーを返さなくなるまで再試行する
+// すべての試行が失敗した場合は、試行ごとのエラーを試行の回数とともにまとめた *errs.Errors を返す
+func Run4[V1 any, V2 any, V3 any, V4 any](s Strategy, f func() (V1, V2, V3, V4, error)) (v1 V1, v2 V2, v3 V3, v4 V4, err error) {
+	return Run4Context(context.Background(), s, f)
+}
+
+// リトライ戦略に従って、関数がエラーを返さなくなるまで再試行する
+// すべての試行が失敗した場合は、試行ごとのエラーを試行の回数とともにまとめた *errs.Errors を返す
+func Run5[V1 any, V2 any, V3 any, V4 any, V5 any](s Strategy, f func() (V1, V2, V3, V4, V5, error)) (v1 V1, v2 V2, v3 V3, v4 V4, v5 V5, err error) {
+	return Run5Context(context.Background(), s, f)
+}
+
+// リトライ戦略に従って、関数がエラーを返さなくなるまで再試行する
+// すべての試行が失敗した場合は、試行ごとのエラーを試行の回数とともにまとめた *errs.Errors を返す
+func Run6[V1 any, V2 any, V3 any, V4 any, V5 any, V6 any](s Strategy, f func() (V1, V2, V3, V4, V5, V6, error)) (v1 V1, v2 V2, v3 V3, v4 V4, v5 V5, v6 V6, err error) {
+	return Run6Context(context.Background(), s, f)
+}
+
+// リトライ戦略に従って、関数がエラーを返さなくなるまで再試行する
+// すべての試行が失敗した場合は、試行ごとのエラーを試行の回数とともにまとめた *errs.Errors を返す
+// ctx が終了した場合は、待っている途中でも再試行をやめて ctx.Err() を返す
+func RunContext(ctx context.Context, s Strategy, f func() error) (err error) {
 	if s == nil {
 		s = defaultStrategy
 	}
-	for c := 0; s.call(c, func() bool { err = f(); return err == nil }); c++ {
+	es := errs.New()
+	for c := 0; s.call(ctx, c, func() bool { err = f(); es.Add(c, err); return err == nil }); c++ {
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		} else {
+			err = es
+		}
 	}
 	return
 }
 
 // リトライ戦略に従って、関数がエラーを返さなくなるまで再試行する
-func Run1[V1 any](s Strategy, f func() (V1, error)) (v1 V1, err error) {
+// すべての試行が失敗した場合は、試行ごとのエラーを試行の回数とともにまとめた *errs.Errors を返す
+// ctx が終了した場合は、待っている途中でも再試行をやめて ctx.Err() を返す
+func Run1Context[V1 any](ctx context.Context, s Strategy, f func() (V1, error)) (v1 V1, err error) {
 	if s == nil {
 		s = defaultStrategy
 	}
-	for c := 0; s.call(c, func() bool { v1, err = f(); return err == nil }); c++ {
+	es := errs.New()
+	for c := 0; s.call(ctx, c, func() bool { v1, err = f(); es.Add(c, err); return err == nil }); c++ {
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		} else {
+			err = es
+		}
 	}
 	return
 }
 
 // リトライ戦略に従って、関数がエラーを返さなくなるまで再試行する
-func Run2[V1 any, V2 any](s Strategy, f func() (V1, V2, error)) (v1 V1, v2 V2, err error) {
+// すべての試行が失敗した場合は、試行ごとのエラーを試行の回数とともにまとめた *errs.Errors を返す
+// ctx が終了した場合は、待っている途中でも再試行をやめて ctx.Err() を返す
+func Run2Context[V1 any, V2 any](ctx context.Context, s Strategy, f func() (V1, V2, error)) (v1 V1, v2 V2, err error) {
 	if s == nil {
 		s = defaultStrategy
 	}
-	for c := 0; s.call(c, func() bool { v1, v2, err = f(); return err == nil }); c++ {
+	es := errs.New()
+	for c := 0; s.call(ctx, c, func() bool { v1, v2, err = f(); es.Add(c, err); return err == nil }); c++ {
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		} else {
+			err = es
+		}
 	}
 	return
 }
 
 // リトライ戦略に従って、関数がエラーを返さなくなるまで再試行する
-func Run3[V1 any, V2 any, V3 any](s Strategy, f func() (V1, V2, V3, error)) (v1 V1, v2 V2, v3 V3, err error) {
+// すべての試行が失敗した場合は、試行ごとのエラーを試行の回数とともにまとめた *errs.Errors を返す
+// ctx が終了した場合は、待っている途中でも再試行をやめて ctx.Err() を返す
+func Run3Context[V1 any, V2 any, V3 any](ctx context.Context, s Strategy, f func() (V1, V2, V3, error)) (v1 V1, v2 V2, v3 V3, err error) {
 	if s == nil {
 		s = defaultStrategy
 	}
-	for c := 0; s.call(c, func() bool { v1, v2, v3, err = f(); return err == nil }); c++ {
+	es := errs.New()
+	for c := 0; s.call(ctx, c, func() bool { v1, v2, v3, err = f(); es.Add(c, err); return err == nil }); c++ {
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		} else {
+			err = es
+		}
 	}
 	return
 }
 
 // リトライ戦略に従って、関数がエラーを返さなくなるまで再試行する
-func Run4[V1 any, V2 any, V3 any, V4 any](s Strategy, f func() (V1, V2, V3, V4, error)) (v1 V1, v2 V2, v3 V3, v4 V4, err error) {
+// すべての試行が失敗した場合は、試行ごとのエラーを試行の回数とともにまとめた *errs.Errors を返す
+// ctx が終了した場合は、待っている途中でも再試行をやめて ctx.Err() を返す
+func Run4Context[V1 any, V2 any, V3 any, V4 any](ctx context.Context, s Strategy, f func() (V1, V2, V3, V4, error)) (v1 V1, v2 V2, v3 V3, v4 V4, err error) {
 	if s == nil {
 		s = defaultStrategy
 	}
-	for c := 0; s.call(c, func() bool { v1, v2, v3, v4, err = f(); return err == nil }); c++ {
+	es := errs.New()
+	for c := 0; s.call(ctx, c, func() bool { v1, v2, v3, v4, err = f(); es.Add(c, err); return err == nil }); c++ {
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		} else {
+			err = es
+		}
 	}
 	return
 }
 
 // リトライ戦略に従って、関数がエラーを返さなくなるまで再試行する
-func Run5[V1 any, V2 any, V3 any, V4 any, V5 any](s Strategy, f func() (V1, V2, V3, V4, V5, error)) (v1 V1, v2 V2, v3 V3, v4 V4, v5 V5, err error) {
+// すべての試行が失敗した場合は、試行ごとのエラーを試行の回数とともにまとめた *errs.Errors を返す
+// ctx が終了した場合は、待っている途中でも再試行をやめて ctx.Err() を返す
+func Run5Context[V1 any, V2 any, V3 any, V4 any, V5 any](ctx context.Context, s Strategy, f func() (V1, V2, V3, V4, V5, error)) (v1 V1, v2 V2, v3 V3, v4 V4, v5 V5, err error) {
 	if s == nil {
 		s = defaultStrategy
 	}
-	for c := 0; s.call(c, func() bool { v1, v2, v3, v4, v5, err = f(); return err == nil }); c++ {
+	es := errs.New()
+	for c := 0; s.call(ctx, c, func() bool { v1, v2, v3, v4, v5, err = f(); es.Add(c, err); return err == nil }); c++ {
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		} else {
+			err = es
+		}
 	}
 	return
 }
 
 // リトライ戦略に従って、関数がエラーを返さなくなるまで再試行する
-func Run6[V1 any, V2 any, V3 any, V4 any, V5 any, V6 any](s Strategy, f func() (V1, V2, V3, V4, V5, V6, error)) (v1 V1, v2 V2, v3 V3, v4 V4, v5 V5, v6 V6, err error) {
+// すべての試行が失敗した場合は、試行ごとのエラーを試行の回数とともにまとめた *errs.Errors を返す
+// ctx が終了した場合は、待っている途中でも再試行をやめて ctx.Err() を返す
+func Run6Context[V1 any, V2 any, V3 any, V4 any, V5 any, V6 any](ctx context.Context, s Strategy, f func() (V1, V2, V3, V4, V5, V6, error)) (v1 V1, v2 V2, v3 V3, v4 V4, v5 V5, v6 V6, err error) {
 	if s == nil {
 		s = defaultStrategy
 	}
-	for c := 0; s.call(c, func() bool { v1, v2, v3, v4, v5, v6, err = f(); return err == nil }); c++ {
+	es := errs.New()
+	for c := 0; s.call(ctx, c, func() bool { v1, v2, v3, v4, v5, v6, err = f(); es.Add(c, err); return err == nil }); c++ {
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		} else {
+			err = es
+		}
 	}
 	return
 }
+
+// ctx が終了するか d が経過するまで待つ。ctx が終了した場合は false を返す
+func wait(ctx context.Context, d time.Duration) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if d <= 0 {
+		return true
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
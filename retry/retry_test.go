@@ -0,0 +1,58 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errTest = errors.New("test")
+
+func TestRun(t *testing.T) {
+	calls := 0
+	err := Run(Simple().WithMaxRetry(2), func() error {
+		calls++
+		return errTest
+	})
+	if !errors.Is(err, errTest) || calls != 3 {
+		t.Fatalf("err = %v, calls = %d", err, calls)
+	}
+}
+
+func TestRunContextStopsWaiting(t *testing.T) {
+	strategies := []Strategy{
+		Simple().WithDelay(time.Hour),
+		ExponentialBackoff().WithDelay(time.Hour),
+	}
+	for _, s := range strategies {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+
+		calls := 0
+		start := time.Now()
+		err := RunContext(ctx, s, func() error {
+			calls++
+			return errTest
+		})
+		if !errors.Is(err, context.Canceled) || calls != 1 {
+			t.Fatalf("err = %v, calls = %d", err, calls)
+		}
+		if d := time.Since(start); d > time.Second {
+			t.Fatalf("RunContext took %v", d)
+		}
+	}
+}
+
+func TestRunContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls := 0
+	err := RunContext(ctx, Simple(), func() error {
+		calls++
+		return errTest
+	})
+	if !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Fatalf("err = %v, calls = %d", err, calls)
+	}
+}
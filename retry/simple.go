@@ -1,6 +1,7 @@
 package retry
 
 import (
+	"context"
 	"time"
 )
 
@@ -26,7 +27,7 @@ func (simple *simple) WithDelay(delay time.Duration) *simple {
 	return simple
 }
 
-func (simple simple) call(n int, f func() bool) bool {
+func (simple simple) call(ctx context.Context, n int, f func() bool) bool {
 	if f() {
 		return false
 	}
@@ -35,9 +36,5 @@ func (simple simple) call(n int, f func() bool) bool {
 		return false
 	}
 
-	if simple.Delay > 0 {
-		time.Sleep(simple.Delay)
-	}
-
-	return true
+	return wait(ctx, simple.Delay)
 }
@@ -78,10 +78,11 @@ func FromMapValues[K comparable, V any](m map[K]V) []V {
 }
 
 // イテレータからスライスをつくる。
-func FromIter[V any](iter iter.Iter[V]) []V {
+func FromIter[V any](it iter.Iter[V]) []V {
+	defer iter.Close(it)
 	slice := []V{}
 	for {
-		v, ok := iter.Next()
+		v, ok := it.Next()
 		if !ok {
 			break
 		}
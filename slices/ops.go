@@ -129,11 +129,80 @@ func Clone[S ~[]V, V any](slice S) S {
 	return clone
 }
 
-// 要素を１つランダムに返す。
+// 要素を１つランダムに返す。スライスが空の場合はゼロ値を返す。
 func Sample[V any](slice []V, r *rand.Rand) V {
+	if len(slice) == 0 {
+		return *new(V)
+	}
 	return slice[r.Intn(len(slice))]
 }
 
+// 要素をランダムに最大k個、重複なく選んで返す。
+func SampleN[V any](slice []V, k int, r *rand.Rand) []V {
+	if k > len(slice) {
+		k = len(slice)
+	}
+	if k <= 0 {
+		return []V{}
+	}
+	// 選んだ位置と末尾の要素を入れ替えたことにして、元のスライスを変更しない。
+	swapped := map[int]int{}
+	dst := make([]V, 0, k)
+	for i := 0; i < k; i++ {
+		j := i + r.Intn(len(slice)-i)
+		n, ok := swapped[j]
+		if !ok {
+			n = j
+		}
+		m, ok := swapped[i]
+		if !ok {
+			m = i
+		}
+		swapped[j] = m
+		dst = append(dst, slice[n])
+	}
+	return dst
+}
+
+// 関数の返す重みに比例した確率で、要素を１つランダムに返す。
+// 重みが 0 以下の要素は選ばれない。選べる要素が無い場合はゼロ値を返す。
+func WeightedSample[V any](slice []V, weight func(V) (float64, error), r *rand.Rand) (V, error) {
+	weights := make([]float64, len(slice))
+	total := 0.0
+	for i := range slice {
+		w, err := weight(slice[i])
+		if err != nil {
+			return *new(V), err
+		}
+		if w > 0 {
+			weights[i] = w
+			total += w
+		}
+	}
+	if total <= 0 {
+		return *new(V), nil
+	}
+	x := r.Float64() * total
+	last := 0
+	for i, w := range weights {
+		if w <= 0 {
+			continue
+		}
+		if x < w {
+			return slice[i], nil
+		}
+		x -= w
+		last = i
+	}
+	// 丸め誤差で選べなかった場合は最後の要素を返す。
+	return slice[last], nil
+}
+
+// 関数の返す重みに比例した確率で、要素を１つランダムに返す。実行中にエラーが起きた場合 panic する。
+func MustWeightedSample[V any](slice []V, weight func(V) (float64, error), r *rand.Rand) V {
+	return must.Must1(WeightedSample(slice, weight, r))
+}
+
 // 逆順にしたスライスを返す。
 func Reverse[S ~[]V, V any](slice []V) S {
 	dst := make(S, 0, len(slice))
@@ -411,6 +480,53 @@ func MustFold[V1 any, V2 any](slice []V1, v V2, f func(V2, V1) (V2, error)) V2 {
 	return must.Must1(Fold(slice, v, f))
 }
 
+// 初期値と値を順に演算し、途中の結果を並べたスライスを返す。
+func Scan[V1 any, V2 any](slice []V1, v V2, f func(V2, V1) (V2, error)) ([]V2, error) {
+	dst := make([]V2, 0, len(slice))
+	var err error
+	for i := range slice {
+		v, err = f(v, slice[i])
+		if err != nil {
+			return nil, err
+		}
+		dst = append(dst, v)
+	}
+	return dst, nil
+}
+
+// 初期値と値を順に演算し、途中の結果を並べたスライスを返す。実行中にエラーが起きた場合 panic する。
+func MustScan[V1 any, V2 any](slice []V1, v V2, f func(V2, V1) (V2, error)) []V2 {
+	return must.Must1(Scan(slice, v, f))
+}
+
+// 状態を持ちながら値を変換したスライスを返す。
+// 関数は次の状態と、０個以上の変換した値を返す。
+// 最後に flush が返す値が加えられる。flush は nil でもよい。
+func MapWithState[V1 any, S any, V2 any](slice []V1, state S, f func(S, V1) (S, []V2, error), flush func(S) ([]V2, error)) ([]V2, error) {
+	dst := []V2{}
+	for i := range slice {
+		next, values, err := f(state, slice[i])
+		if err != nil {
+			return nil, err
+		}
+		state = next
+		dst = append(dst, values...)
+	}
+	if flush != nil {
+		values, err := flush(state)
+		if err != nil {
+			return nil, err
+		}
+		dst = append(dst, values...)
+	}
+	return dst, nil
+}
+
+// 状態を持ちながら値を変換したスライスを返す。実行中にエラーが起きた場合 panic する。
+func MustMapWithState[V1 any, S any, V2 any](slice []V1, state S, f func(S, V1) (S, []V2, error), flush func(S) ([]V2, error)) []V2 {
+	return must.Must1(MapWithState(slice, state, f, flush))
+}
+
 // 条件を満たす最初の値の位置を返す。
 func IndexBy[V any](slice []V, f func(V) (bool, error)) (int, error) {
 	for i := range slice {
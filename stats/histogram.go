@@ -0,0 +1,99 @@
+package stats
+
+import (
+	"sort"
+
+	"github.com/thamaji/gu/iter"
+	"github.com/thamaji/gu/must"
+)
+
+// 値の度数分布。
+// Counts[i] は Bounds[i-1] 以上 Bounds[i] 未満の値の数。
+// Counts[0] は Bounds[0] 未満、Counts[len(Bounds)] は最後の境界以上の値の数。
+type Histogram struct {
+	Bounds []float64
+	Counts []int
+}
+
+// 境界を指定して度数分布をつくる。境界はソートされる。
+func NewHistogram(bounds ...float64) *Histogram {
+	b := append(make([]float64, 0, len(bounds)), bounds...)
+	sort.Float64s(b)
+	return &Histogram{
+		Bounds: b,
+		Counts: make([]int, len(b)+1),
+	}
+}
+
+// 値を追加する。
+func (h *Histogram) Add(v float64) {
+	h.Counts[sort.Search(len(h.Bounds), func(i int) bool { return v < h.Bounds[i] })]++
+}
+
+// 値の数を返す。
+func (h *Histogram) Count() int {
+	c := 0
+	for _, n := range h.Counts {
+		c += n
+	}
+	return c
+}
+
+// start から width ずつ増えるn個の境界を返す。
+func LinearBuckets(start float64, width float64, n int) []float64 {
+	bounds := make([]float64, n)
+	for i := range bounds {
+		bounds[i] = start + width*float64(i)
+	}
+	return bounds
+}
+
+// start から factor 倍ずつ増えるn個の境界を返す。
+func ExponentialBuckets(start float64, factor float64, n int) []float64 {
+	bounds := make([]float64, n)
+	for i := range bounds {
+		bounds[i] = start
+		start *= factor
+	}
+	return bounds
+}
+
+// 境界を指定して値の度数分布を返す。
+// スライスやマップの値には HistogramOfSlice や HistogramOfMap を使う。
+func HistogramOf[V Number](iter iter.Iter[V], bounds ...float64) (*Histogram, error) {
+	h := NewHistogram(bounds...)
+	for {
+		v, ok := iter.Next()
+		if !ok {
+			break
+		}
+		h.Add(float64(v))
+	}
+	if err := closeIter(iter); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// 境界を指定して値の度数分布を返す。実行中にエラーが起きた場合 panic する。
+func MustHistogramOf[V Number](iter iter.Iter[V], bounds ...float64) *Histogram {
+	return must.Must1(HistogramOf(iter, bounds...))
+}
+
+// 境界を指定してスライスの値の度数分布を返す。
+func HistogramOfSlice[V Number](slice []V, bounds ...float64) *Histogram {
+	h := NewHistogram(bounds...)
+	for _, v := range slice {
+		h.Add(float64(v))
+	}
+	return h
+}
+
+// 境界を指定してマップの値の度数分布を返す。
+func HistogramOfMap[K comparable, V Number](m map[K]V, bounds ...float64) *Histogram {
+	h := NewHistogram(bounds...)
+	for _, v := range m {
+		h.Add(float64(v))
+	}
+	return h
+}
@@ -0,0 +1,47 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/thamaji/gu/iter"
+)
+
+func TestHistogramOf(t *testing.T) {
+	values := []float64{-1, 0, 0.5, 1, 2, 9, 10, 11}
+	bounds := LinearBuckets(0, 5, 3) // 0, 5, 10
+	want := []int{1, 4, 1, 2}
+
+	fromIter, err := HistogramOf(iter.FromSlice(values), bounds...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := map[int]float64{}
+	for i, v := range values {
+		m[i] = v
+	}
+	for name, h := range map[string]*Histogram{
+		"HistogramOf":      fromIter,
+		"HistogramOfSlice": HistogramOfSlice(values, bounds...),
+		"HistogramOfMap":   HistogramOfMap(m, bounds...),
+	} {
+		if h.Count() != len(values) {
+			t.Errorf("%s: count %d, want %d", name, h.Count(), len(values))
+		}
+		for i := range want {
+			if h.Counts[i] != want[i] {
+				t.Errorf("%s: counts %v, want %v", name, h.Counts, want)
+				break
+			}
+		}
+	}
+}
+
+func TestExponentialBuckets(t *testing.T) {
+	got := ExponentialBuckets(1, 2, 4)
+	want := []float64{1, 2, 4, 8}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package stats
+
+import (
+	"math"
+	"sort"
+
+	"github.com/thamaji/gu/iter"
+	"github.com/thamaji/gu/must"
+)
+
+// スライスのp分位数（0 <= p <= 1）を線形補間で返す。値が無い場合は NaN を返す。
+// スライスは変更されない。
+func Quantile[V Number](slice []V, p float64) float64 {
+	return Quantiles(slice, p)[0]
+}
+
+// スライスの中央値を返す。値が無い場合は NaN を返す。
+func Median[V Number](slice []V) float64 {
+	return Quantile(slice, 0.5)
+}
+
+// スライスの複数のp分位数を返す。ソートは一度だけ行われる。
+func Quantiles[V Number](slice []V, ps ...float64) []float64 {
+	sorted := make([]float64, len(slice))
+	for i, v := range slice {
+		sorted[i] = float64(v)
+	}
+	sort.Float64s(sorted)
+	dst := make([]float64, len(ps))
+	for i, p := range ps {
+		dst[i] = quantileSorted(sorted, p)
+	}
+	return dst
+}
+
+func quantileSorted(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 || math.IsNaN(p) {
+		return math.NaN()
+	}
+	if p <= 0 {
+		return sorted[0]
+	}
+	if p >= 1 {
+		return sorted[len(sorted)-1]
+	}
+	h := p * float64(len(sorted)-1)
+	i := int(h)
+	if i+1 >= len(sorted) {
+		return sorted[i]
+	}
+	return sorted[i] + (h-float64(i))*(sorted[i+1]-sorted[i])
+}
+
+// p分位数を P² アルゴリズムで近似する。保持する値は５つだけで済む。
+type P2Quantile struct {
+	p     float64
+	count int
+	q     [5]float64 // 推定した高さ
+	n     [5]int     // 実際の位置
+	ns    [5]float64 // 理想の位置
+	dn    [5]float64 // 理想の位置の増分
+}
+
+// p分位数（0 <= p <= 1）を近似する P2Quantile をつくる。
+func NewP2Quantile(p float64) *P2Quantile {
+	return &P2Quantile{p: p}
+}
+
+// 値を追加する。
+func (e *P2Quantile) Add(v float64) {
+	if e.count < 5 {
+		e.q[e.count] = v
+		e.count++
+		if e.count == 5 {
+			sort.Float64s(e.q[:])
+			p := e.p
+			e.n = [5]int{1, 2, 3, 4, 5}
+			e.ns = [5]float64{1, 1 + 2*p, 1 + 4*p, 3 + 2*p, 5}
+			e.dn = [5]float64{0, p / 2, p, (1 + p) / 2, 1}
+		}
+		return
+	}
+	e.count++
+
+	var k int
+	switch {
+	case v < e.q[0]:
+		e.q[0] = v
+		k = 0
+	case v < e.q[1]:
+		k = 0
+	case v < e.q[2]:
+		k = 1
+	case v < e.q[3]:
+		k = 2
+	case v <= e.q[4]:
+		k = 3
+	default:
+		e.q[4] = v
+		k = 3
+	}
+	for i := k + 1; i < 5; i++ {
+		e.n[i]++
+	}
+	for i := range e.ns {
+		e.ns[i] += e.dn[i]
+	}
+
+	for i := 1; i <= 3; i++ {
+		d := e.ns[i] - float64(e.n[i])
+		if (d >= 1 && e.n[i+1]-e.n[i] > 1) || (d <= -1 && e.n[i-1]-e.n[i] < -1) {
+			s := 1
+			if d < 0 {
+				s = -1
+			}
+			q := e.parabolic(i, float64(s))
+			if e.q[i-1] < q && q < e.q[i+1] {
+				e.q[i] = q
+			} else {
+				e.q[i] = e.linear(i, s)
+			}
+			e.n[i] += s
+		}
+	}
+}
+
+func (e *P2Quantile) parabolic(i int, d float64) float64 {
+	n0, n1, n2 := float64(e.n[i-1]), float64(e.n[i]), float64(e.n[i+1])
+	return e.q[i] + d/(n2-n0)*((n1-n0+d)*(e.q[i+1]-e.q[i])/(n2-n1)+(n2-n1-d)*(e.q[i]-e.q[i-1])/(n1-n0))
+}
+
+func (e *P2Quantile) linear(i int, d int) float64 {
+	return e.q[i] + float64(d)*(e.q[i+d]-e.q[i])/float64(e.n[i+d]-e.n[i])
+}
+
+// 値の数を返す。
+func (e *P2Quantile) Count() int {
+	return e.count
+}
+
+// 近似したp分位数を返す。値が無い場合は NaN を返す。
+func (e *P2Quantile) Value() float64 {
+	if e.count < 5 {
+		sorted := append([]float64{}, e.q[:e.count]...)
+		sort.Float64s(sorted)
+		return quantileSorted(sorted, e.p)
+	}
+	return e.q[2]
+}
+
+// 値のp分位数を P² アルゴリズムで近似して返す。値が無い場合は NaN を返す。
+func ApproxQuantile[V Number](iter iter.Iter[V], p float64) (float64, error) {
+	e := NewP2Quantile(p)
+	for {
+		v, ok := iter.Next()
+		if !ok {
+			break
+		}
+		e.Add(float64(v))
+	}
+	if err := closeIter(iter); err != nil {
+		return 0, err
+	}
+	return e.Value(), nil
+}
+
+// 値のp分位数を P² アルゴリズムで近似して返す。値が無い場合は NaN を返す。実行中にエラーが起きた場合 panic する。
+func MustApproxQuantile[V Number](iter iter.Iter[V], p float64) float64 {
+	return must.Must1(ApproxQuantile(iter, p))
+}
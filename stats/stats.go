@@ -0,0 +1,251 @@
+package stats
+
+import (
+	"math"
+
+	"github.com/thamaji/gu/iter"
+	"github.com/thamaji/gu/must"
+	"golang.org/x/exp/constraints"
+)
+
+type Number interface {
+	constraints.Integer | constraints.Float
+}
+
+// 平均と分散を Welford のアルゴリズムで逐次計算する。
+type Moments struct {
+	count int
+	mean  float64
+	m2    float64
+	min   float64
+	max   float64
+}
+
+// 値を追加する。
+func (m *Moments) Add(v float64) {
+	m.count++
+	if m.count == 1 {
+		m.min, m.max = v, v
+	} else if v < m.min {
+		m.min = v
+	} else if v > m.max {
+		m.max = v
+	}
+	d := v - m.mean
+	m.mean += d / float64(m.count)
+	m.m2 += d * (v - m.mean)
+}
+
+// 値の数を返す。
+func (m *Moments) Count() int {
+	return m.count
+}
+
+// 平均を返す。値が無い場合は NaN を返す。
+func (m *Moments) Mean() float64 {
+	if m.count == 0 {
+		return math.NaN()
+	}
+	return m.mean
+}
+
+// 母分散を返す。値が無い場合は NaN を返す。
+func (m *Moments) Variance() float64 {
+	if m.count == 0 {
+		return math.NaN()
+	}
+	return m.m2 / float64(m.count)
+}
+
+// 不偏分散を返す。値が２つ未満の場合は NaN を返す。
+func (m *Moments) SampleVariance() float64 {
+	if m.count < 2 {
+		return math.NaN()
+	}
+	return m.m2 / float64(m.count-1)
+}
+
+// 母標準偏差を返す。値が無い場合は NaN を返す。
+func (m *Moments) Stddev() float64 {
+	return math.Sqrt(m.Variance())
+}
+
+// 不偏分散の平方根を返す。値が２つ未満の場合は NaN を返す。
+func (m *Moments) SampleStddev() float64 {
+	return math.Sqrt(m.SampleVariance())
+}
+
+// 最小の値を返す。値が無い場合は NaN を返す。
+func (m *Moments) Min() float64 {
+	if m.count == 0 {
+		return math.NaN()
+	}
+	return m.min
+}
+
+// 最大の値を返す。値が無い場合は NaN を返す。
+func (m *Moments) Max() float64 {
+	if m.count == 0 {
+		return math.NaN()
+	}
+	return m.max
+}
+
+// 値の平均・分散などを一度に計算する。
+// スライスやマップの値には SummarizeSlice や SummarizeMap を使う。
+func Summarize[V Number](iter iter.Iter[V]) (*Moments, error) {
+	m := &Moments{}
+	for {
+		v, ok := iter.Next()
+		if !ok {
+			break
+		}
+		m.Add(float64(v))
+	}
+	if err := closeIter(iter); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// 値の平均・分散などを一度に計算する。実行中にエラーが起きた場合 panic する。
+func MustSummarize[V Number](iter iter.Iter[V]) *Moments {
+	return must.Must1(Summarize(iter))
+}
+
+// スライスの値の平均・分散などを一度に計算する。
+func SummarizeSlice[V Number](slice []V) *Moments {
+	m := &Moments{}
+	for _, v := range slice {
+		m.Add(float64(v))
+	}
+	return m
+}
+
+// マップの値の平均・分散などを一度に計算する。
+func SummarizeMap[K comparable, V Number](m map[K]V) *Moments {
+	moments := &Moments{}
+	for _, v := range m {
+		moments.Add(float64(v))
+	}
+	return moments
+}
+
+// 値の平均を返す。値が無い場合は NaN を返す。
+func Mean[V Number](iter iter.Iter[V]) (float64, error) {
+	m, err := Summarize(iter)
+	if err != nil {
+		return 0, err
+	}
+	return m.Mean(), nil
+}
+
+// 値の平均を返す。値が無い場合は NaN を返す。実行中にエラーが起きた場合 panic する。
+func MustMean[V Number](iter iter.Iter[V]) float64 {
+	return must.Must1(Mean(iter))
+}
+
+// 値の母分散を返す。値が無い場合は NaN を返す。
+func Variance[V Number](iter iter.Iter[V]) (float64, error) {
+	m, err := Summarize(iter)
+	if err != nil {
+		return 0, err
+	}
+	return m.Variance(), nil
+}
+
+// 値の母分散を返す。値が無い場合は NaN を返す。実行中にエラーが起きた場合 panic する。
+func MustVariance[V Number](iter iter.Iter[V]) float64 {
+	return must.Must1(Variance(iter))
+}
+
+// 値の母標準偏差を返す。値が無い場合は NaN を返す。
+func Stddev[V Number](iter iter.Iter[V]) (float64, error) {
+	m, err := Summarize(iter)
+	if err != nil {
+		return 0, err
+	}
+	return m.Stddev(), nil
+}
+
+// 値の母標準偏差を返す。値が無い場合は NaN を返す。実行中にエラーが起きた場合 panic する。
+func MustStddev[V Number](iter iter.Iter[V]) float64 {
+	return must.Must1(Stddev(iter))
+}
+
+// 最も多く現れる値と、その数を返す。同数の場合は先に現れた値を返す。
+// 第３戻り値は値があるときは true、なければ false を返す。
+// スライスやマップの値には ModeSlice や ModeMap を使う。
+func Mode[V comparable](iter iter.Iter[V]) (V, int, bool, error) {
+	c := newModeCounter[V]()
+	for {
+		v, ok := iter.Next()
+		if !ok {
+			break
+		}
+		c.add(v)
+	}
+	if err := closeIter(iter); err != nil {
+		return *new(V), 0, false, err
+	}
+	return c.mode, c.top, c.top > 0, nil
+}
+
+// 最も多く現れる値と、その数を返す。同数の場合は先に現れた値を返す。実行中にエラーが起きた場合 panic する。
+// 第３戻り値は値があるときは true、なければ false を返す。
+func MustMode[V comparable](iter iter.Iter[V]) (V, int, bool) {
+	return must.Must3(Mode(iter))
+}
+
+// スライスで最も多く現れる値と、その数を返す。同数の場合は先に現れた値を返す。
+// 第３戻り値は値があるときは true、なければ false を返す。
+func ModeSlice[V comparable](slice []V) (V, int, bool) {
+	c := newModeCounter[V]()
+	for _, v := range slice {
+		c.add(v)
+	}
+	return c.mode, c.top, c.top > 0
+}
+
+// マップの値で最も多く現れる値と、その数を返す。同数の場合にどの値を返すかは決まっていない。
+// 第３戻り値は値があるときは true、なければ false を返す。
+func ModeMap[K comparable, V comparable](m map[K]V) (V, int, bool) {
+	c := newModeCounter[V]()
+	for _, v := range m {
+		c.add(v)
+	}
+	return c.mode, c.top, c.top > 0
+}
+
+// 値の数を数えて、最も多く現れた値を記録する。同数の場合は先に現れた値を残す。
+type modeCounter[V comparable] struct {
+	counts map[V]int
+	first  map[V]int // 最初に現れた位置
+	n      int
+	mode   V
+	top    int
+}
+
+func newModeCounter[V comparable]() *modeCounter[V] {
+	return &modeCounter[V]{counts: map[V]int{}, first: map[V]int{}}
+}
+
+func (c *modeCounter[V]) add(v V) {
+	if _, ok := c.first[v]; !ok {
+		c.first[v] = c.n
+	}
+	c.n++
+	c.counts[v]++
+	if c.counts[v] > c.top || (c.counts[v] == c.top && c.first[v] < c.first[c.mode]) {
+		c.mode, c.top = v, c.counts[v]
+	}
+}
+
+// 読み終えたイテレータを閉じ、エラーを返す。
+func closeIter[V any](it iter.Iter[V]) error {
+	err := it.Err()
+	if err1 := iter.Close(it); err == nil {
+		err = err1
+	}
+	return err
+}
@@ -0,0 +1,65 @@
+package stats
+
+import (
+	"math"
+	"testing"
+
+	"github.com/thamaji/gu/iter"
+)
+
+func approx(a float64, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestSummarize(t *testing.T) {
+	values := []int{2, 4, 4, 4, 5, 5, 7, 9}
+	m := map[string]int{}
+	for i, v := range values {
+		m[string(rune('a'+i))] = v
+	}
+
+	fromIter, err := Summarize(iter.FromSlice(values))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, got := range map[string]*Moments{
+		"Summarize":      fromIter,
+		"SummarizeSlice": SummarizeSlice(values),
+		"SummarizeMap":   SummarizeMap(m),
+	} {
+		if got.Count() != 8 || !approx(got.Mean(), 5) || !approx(got.Variance(), 4) || !approx(got.Stddev(), 2) {
+			t.Errorf("%s: count %d, mean %v, variance %v, stddev %v", name, got.Count(), got.Mean(), got.Variance(), got.Stddev())
+		}
+		if !approx(got.SampleVariance(), 32.0/7) || got.Min() != 2 || got.Max() != 9 {
+			t.Errorf("%s: sample variance %v, min %v, max %v", name, got.SampleVariance(), got.Min(), got.Max())
+		}
+	}
+}
+
+func TestSummarizeEmpty(t *testing.T) {
+	m := SummarizeSlice([]float64{})
+	if m.Count() != 0 || !math.IsNaN(m.Mean()) || !math.IsNaN(m.Variance()) {
+		t.Fatalf("count %d, mean %v, variance %v", m.Count(), m.Mean(), m.Variance())
+	}
+}
+
+func TestMode(t *testing.T) {
+	// 2 と 1 が同数なので、先に現れた 2 を返す。
+	values := []int{2, 1, 1, 3, 2}
+
+	v, n, ok, err := Mode(iter.FromSlice(values))
+	if err != nil || v != 2 || n != 2 || !ok {
+		t.Fatalf("Mode() = %v, %v, %v, %v", v, n, ok, err)
+	}
+	v, n, ok = ModeSlice(values)
+	if v != 2 || n != 2 || !ok {
+		t.Fatalf("ModeSlice() = %v, %v, %v", v, n, ok)
+	}
+	v, n, ok = ModeMap(map[string]int{"a": 1, "b": 3, "c": 3})
+	if v != 3 || n != 2 || !ok {
+		t.Fatalf("ModeMap() = %v, %v, %v", v, n, ok)
+	}
+	if _, _, ok := ModeSlice([]int{}); ok {
+		t.Fatal("ModeSlice() of no values returned ok")
+	}
+}